@@ -0,0 +1,260 @@
+package admin
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/api/endpoints/users"
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/firebaseclient"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/Krishap-s/keats-backend/validation"
+)
+
+// Non Handlers
+
+// requireRole lets only users holding one of the platform roles through
+func requireRole(roles ...string) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(*models.User)
+		if !ok {
+			return errors.ErrNotAdmin
+		}
+		for _, role := range roles {
+			if user.Role == role {
+				return c.Next()
+			}
+		}
+		return errors.ErrNotAdmin
+	}
+}
+
+func pageQuery(c *fiber.Ctx) int {
+	n, err := strconv.Atoi(c.Query("page", "0"))
+	if err != nil || n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// Handlers
+
+func searchUsers(c *fiber.Ctx) error {
+	found, err := crud.SearchUser(c.Query("q"), pageQuery(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   found,
+	})
+}
+
+func searchClubs(c *fiber.Ctx) error {
+	clubs, err := crud.SearchAdminClub(c.Query("q"), pageQuery(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   clubs,
+	})
+}
+
+func suspendUser(c *fiber.Ctx) error {
+	r := new(struct {
+		UserID string `json:"user_id"`
+		Days   int    `json:"days"`
+	})
+	if err := c.BodyParser(r); err != nil || r.UserID == "" || r.Days < 0 {
+		return errors.ErrMalformedJSON
+	}
+	// Suspensions without an end run until lifted
+	until := time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	if r.Days > 0 {
+		until = time.Now().AddDate(0, 0, r.Days)
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	if err = crud.SetUserSuspension(uid, r.UserID, &until, users.NewAuditLog(c, "suspend_user")); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User has been suspended",
+	})
+}
+
+func unsuspendUser(c *fiber.Ctx) error {
+	r := new(struct {
+		UserID string `json:"user_id"`
+	})
+	if err := c.BodyParser(r); err != nil || r.UserID == "" {
+		return errors.ErrMalformedJSON
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	if err = crud.SetUserSuspension(uid, r.UserID, nil, users.NewAuditLog(c, "unsuspend_user")); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User suspension has been lifted",
+	})
+}
+
+func setRole(c *fiber.Ctx) error {
+	r := new(struct {
+		UserID string `json:"user_id"`
+		Role   string `json:"role"`
+	})
+	if err := c.BodyParser(r); err != nil || r.UserID == "" {
+		return errors.ErrMalformedJSON
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	// Admins stepping down go through another admin so one is always left
+	if uid == r.UserID {
+		return errors.ErrSelfRole
+	}
+	if err = crud.SetUserRole(r.UserID, r.Role, users.NewAuditLog(c, "set_role")); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User role has been updated",
+	})
+}
+
+func transferClub(c *fiber.Ctx) error {
+	r := new(struct {
+		ClubID string `json:"club_id"`
+		UserID string `json:"user_id"`
+	})
+	if err := c.BodyParser(r); err != nil || r.ClubID == "" || r.UserID == "" {
+		return errors.ErrMalformedJSON
+	}
+	club, err := crud.TransferClubHost(r.ClubID, r.UserID, users.NewAuditLog(c, "transfer_club"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   club,
+	})
+}
+
+func deleteClub(c *fiber.Ctx) error {
+	r := new(struct {
+		ClubID string `json:"club_id"`
+	})
+	if err := c.BodyParser(r); err != nil || r.ClubID == "" {
+		return errors.ErrMalformedJSON
+	}
+	deleted, err := crud.DeleteClub(r.ClubID, users.NewAuditLog(c, "admin_delete_club"))
+	if err != nil {
+		return err
+	}
+	for _, fileURL := range []string{deleted.ClubPic, deleted.FileURL} {
+		if err = firebaseclient.DeleteObject(fileURL); err != nil {
+			log.Println("Storage error:", err)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Club has been deleted",
+	})
+}
+
+func listReports(c *fiber.Ctx) error {
+	reports, err := crud.ListReport(c.Query("status"), pageQuery(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   reports,
+	})
+}
+
+func reviewReport(c *fiber.Ctx) error {
+	r := new(schemas.ReportReview)
+	if err := c.BodyParser(r); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	if err := validation.Validate(r); err != nil {
+		return err
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	report, err := crud.ReviewReport(uid, r, users.NewAuditLog(c, "review_report"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+func hideContent(c *fiber.Ctx) error {
+	r := new(struct {
+		TargetType string `json:"target_type"`
+		TargetID   string `json:"target_id"`
+		Hidden     bool   `json:"hidden"`
+	})
+	if err := c.BodyParser(r); err != nil || r.TargetType == "" || r.TargetID == "" {
+		return errors.ErrMalformedJSON
+	}
+	err := crud.HideContent(r.TargetType, r.TargetID, r.Hidden, users.NewAuditLog(c, "hide_content"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Content visibility has been updated",
+	})
+}
+
+func listAuditLog(c *fiber.Ctx) error {
+	logs, err := crud.ListAuditLog(c.Query("club_id"), pageQuery(c))
+	if err != nil {
+		return errors.ErrMalformedQuery
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   logs,
+	})
+}
+
+// MountRoutes mounts all routes declared here, moderators can work the report
+// queue while everything else is left to admins
+func MountRoutes(router fiber.Router, middleware func(c *fiber.Ctx) error) {
+	staff := requireRole(models.RoleModerator, models.RoleAdmin)
+	adminOnly := requireRole(models.RoleAdmin)
+	adminGroup := router.Group("/admin", middleware, staff)
+	adminGroup.Get("users", searchUsers)
+	adminGroup.Get("clubs", searchClubs)
+	adminGroup.Get("reports", listReports)
+	adminGroup.Post("reports/review", reviewReport)
+	adminGroup.Post("content/hide", hideContent)
+	adminGroup.Post("users/suspend", suspendUser)
+	adminGroup.Post("users/unsuspend", unsuspendUser)
+	adminGroup.Post("users/role", adminOnly, setRole)
+	adminGroup.Post("clubs/transfer", adminOnly, transferClub)
+	adminGroup.Post("clubs/delete", adminOnly, deleteClub)
+	adminGroup.Get("audit", adminOnly, listAuditLog)
+}
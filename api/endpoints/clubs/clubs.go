@@ -1,20 +1,22 @@
 package clubs
 
 import (
-	"fmt"
-	"mime/multipart"
+	"log"
 	"strconv"
 	"time"
 
 	"github.com/Krishap-s/keats-backend/api/endpoints/users"
 	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
 	"github.com/Krishap-s/keats-backend/firebaseclient"
 	"github.com/Krishap-s/keats-backend/models"
 	"github.com/Krishap-s/keats-backend/redisclient"
 	"github.com/Krishap-s/keats-backend/schemas"
 	"github.com/Krishap-s/keats-backend/utils"
+	"github.com/Krishap-s/keats-backend/validation"
 	"github.com/go-pg/pg/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
@@ -27,7 +29,7 @@ type clubRequests struct {
 func parseClubIDRequest(c *fiber.Ctx) (*clubRequests, error) {
 	r := new(clubRequests)
 	if err := c.BodyParser(r); err != nil || r.ID == "" {
-		return nil, fmt.Errorf("JSON Data Incorrect")
+		return nil, errors.ErrMalformedJSON
 	}
 	return r, nil
 }
@@ -50,14 +52,39 @@ func prepUpdate(c *fiber.Ctx, userID string) error {
 	}
 	check, err := checkIfHost(uid, userID)
 	if err != nil {
-		return fmt.Errorf("club not found")
+		return errors.ErrClubNotFound
 	}
 	if !check {
-		return fmt.Errorf("not host")
+		return errors.ErrNotHost
 	}
 	return nil
 }
 
+// notifyPageSync tells the members of a club that its page sync state or synced page changed
+func notifyPageSync(c *fiber.Ctx, clubID string) error {
+	club, err := crud.GetClub(clubID)
+	if err != nil {
+		return err
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	cid, err := uuid.Parse(club.ID)
+	if err != nil {
+		return err
+	}
+	hostID, err := uuid.Parse(uid)
+	if err != nil {
+		return err
+	}
+	return crud.NotifyClubMembers(cid, hostID, models.NotificationPageSync, map[string]interface{}{
+		"clubname":  club.ClubName,
+		"page_sync": club.PageSync,
+		"page_no":   club.PageNo,
+	})
+}
+
 func updateClubFiles(c *fiber.Ctx) (string, string, error) {
 	var clubPicURL, fileURL string
 	//nolint
@@ -65,7 +92,7 @@ func updateClubFiles(c *fiber.Ctx) (string, string, error) {
 	if clubPicFileHeader != nil {
 		clubPicFile, err := clubPicFileHeader.Open()
 		if err != nil {
-			return "", "", fmt.Errorf("file parse error")
+			return "", "", errors.ErrFileParse
 		}
 		defer utils.CloseFile(clubPicFile)
 		acceptedTypes := []string{"image/png", "image/jpeg"}
@@ -74,15 +101,13 @@ func updateClubFiles(c *fiber.Ctx) (string, string, error) {
 			return "", "", err
 		}
 	}
-	fileHeader, err := c.FormFile("file")
-	if err != nil {
-		return "", "", fmt.Errorf("form Data Incorrect")
-	}
+	// Requests that upload no book, JSON ones included, keep the current one
+	//nolint
+	fileHeader, _ := c.FormFile("file")
 	if fileHeader != nil {
-		var fileFile multipart.File
-		fileFile, err = fileHeader.Open()
+		fileFile, err := fileHeader.Open()
 		if err != nil {
-			return "", "", fmt.Errorf("file parse error")
+			return "", "", errors.ErrFileParse
 		}
 		defer utils.CloseFile(fileFile)
 		acceptedTypes := []string{"application/pdf", "application/epub+xml", "application/epub+zip", "application/zip"}
@@ -118,7 +143,10 @@ func createClub(c *fiber.Ctx) error {
 	pipe := rdb.TxPipeline()
 	r := new(schemas.ClubCreate)
 	if err = c.BodyParser(r); err != nil {
-		return fmt.Errorf("form Data Incorrect")
+		return errors.ErrMalformedForm
+	}
+	if err = validation.Validate(r); err != nil {
+		return err
 	}
 	var uid string
 	uid, err = users.GetUID(c)
@@ -133,7 +161,7 @@ func createClub(c *fiber.Ctx) error {
 		return err
 	}
 	if count.Val() >= maxClubCreated {
-		return fmt.Errorf("max clubs created")
+		return errors.ErrMaxClubsCreated
 	}
 	r.HostID = uid
 	r.ClubPic, r.FileURL, err = updateClubFiles(c)
@@ -160,7 +188,10 @@ func joinClub(c *fiber.Ctx) error {
 	clubID := r.ID
 	club, err := crud.GetClub(clubID)
 	if err != nil {
-		return fmt.Errorf("club not found")
+		return errors.ErrClubNotFound
+	}
+	if club.Archived {
+		return errors.ErrClubArchived
 	}
 	usersList, err := crud.GetClubUser(clubID)
 	if err != nil {
@@ -168,7 +199,7 @@ func joinClub(c *fiber.Ctx) error {
 	}
 	// Shows host user as the first user
 	for i, clubUser := range usersList {
-		if clubUser.ID.String() == club.HostID {
+		if clubUser.ID == club.HostID {
 			usersList[0], usersList[i] = usersList[i], usersList[0]
 			break
 		}
@@ -180,18 +211,17 @@ func joinClub(c *fiber.Ctx) error {
 	}
 	_, err = crud.CreateClubUser(clubID, string(uidBytes))
 	if err != nil {
-		pgErr := err.(pg.Error)
-		if pgErr.IntegrityViolation() {
-			return fmt.Errorf("already member")
+		if pgErr, ok := err.(pg.Error); ok && pgErr.IntegrityViolation() {
+			return errors.ErrAlreadyMember
 		}
 		return err
 	}
-	chatMessages, err := crud.GetChatMessage(clubID)
+	chatMessages, err := crud.GetChatMessage(clubID, user.ID.String())
 	if err != nil {
 		return err
 	}
 
-	comments, err := crud.GetComment(clubID)
+	comments, err := crud.GetComment(clubID, user.ID.String())
 	if err != nil {
 		return err
 	}
@@ -218,12 +248,12 @@ func listClubs(c *fiber.Ctx) error {
 	if err != nil || n < 1 {
 		n = 1
 	}
-	clubs, err := crud.ListClub(uid, n)
+	clubs, err := crud.ListClub(uid, n, c.Query("tag"))
 	if err != nil {
 		return err
 	}
 	if clubs == nil {
-		return fmt.Errorf("no public")
+		return errors.ErrNoPublicClubs
 	}
 	return c.JSON(fiber.Map{
 		"status": "success",
@@ -231,6 +261,90 @@ func listClubs(c *fiber.Ctx) error {
 	})
 }
 
+func listTrendingClubs(c *fiber.Ctx) error {
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	var n int
+	n, err = strconv.Atoi(c.Query("page", "0"))
+	if err != nil || n < 1 {
+		n = 1
+	}
+	clubs, err := crud.ListTrendingClubs(uid, n)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   clubs,
+	})
+}
+
+func listRecommendedClubs(c *fiber.Ctx) error {
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	var n int
+	n, err = strconv.Atoi(c.Query("page", "0"))
+	if err != nil || n < 1 {
+		n = 1
+	}
+	clubs, err := crud.RecommendClubs(uid, n)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   clubs,
+	})
+}
+
+func discoverClubs(c *fiber.Ctx) error {
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	r := new(schemas.ClubSearch)
+	if err = c.QueryParser(r); err != nil {
+		return errors.ErrMalformedQuery
+	}
+	if err = validation.Validate(r); err != nil {
+		return err
+	}
+	page, err := crud.SearchClub(uid, r)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   page,
+	})
+}
+
+func listCategories(c *fiber.Ctx) error {
+	categories, err := crud.ListCategories()
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   categories,
+	})
+}
+
+func autocompleteTags(c *fiber.Ctx) error {
+	tags, err := crud.SearchTags(c.Query("q"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   tags,
+	})
+}
+
 func getClub(c *fiber.Ctx) error {
 	clubID := c.Query("club_id")
 	usersList, err := crud.GetClubUser(clubID)
@@ -238,13 +352,13 @@ func getClub(c *fiber.Ctx) error {
 
 	var isMember = false
 	for _, clubUser := range usersList {
-		if clubUser.ID == user.ID {
+		if clubUser.ID == user.ID.String() {
 			isMember = true
 			break
 		}
 	}
 	if !isMember {
-		return fmt.Errorf("not member")
+		return errors.ErrNotMember
 	}
 	if err != nil {
 		return err
@@ -255,17 +369,17 @@ func getClub(c *fiber.Ctx) error {
 	}
 	// Shows host user as the first user
 	for i, clubUser := range usersList {
-		if clubUser.ID.String() == club.HostID {
+		if clubUser.ID == club.HostID {
 			usersList[0], usersList[i] = usersList[i], usersList[0]
 			break
 		}
 	}
-	chatMessages, err := crud.GetChatMessage(clubID)
+	chatMessages, err := crud.GetChatMessage(clubID, user.ID.String())
 	if err != nil {
 		return err
 	}
 
-	comments, err := crud.GetComment(clubID)
+	comments, err := crud.GetComment(clubID, user.ID.String())
 	if err != nil {
 		return err
 	}
@@ -282,21 +396,45 @@ func getClub(c *fiber.Ctx) error {
 
 func updateClub(c *fiber.Ctx) error {
 	r := new(schemas.ClubUpdate)
-	if err := c.BodyParser(r); err != nil || r.ID == "" {
-		return fmt.Errorf("JSON Data Incorrect")
+	if err := c.BodyParser(r); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	if err := validation.Validate(r); err != nil {
+		return err
 	}
 	if err := prepUpdate(c, r.ID); err != nil {
 		return err
 	}
-	var err error
+	archived, err := crud.IsClubArchived(r.ID)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return errors.ErrClubArchived
+	}
 	r.ClubPic, r.FileURL, err = updateClubFiles(c)
 	if err != nil {
 		return err
 	}
-	updated, err := crud.UpdateClub(r)
+	updated, err := crud.UpdateClub(r, users.NewAuditLog(c, "update_club"))
 	if err != nil {
 		return err
 	}
+	// Moving the synced page is told apart from changes to the club itself
+	if r.PageNo != 0 && updated.PageSync {
+		if err = notifyPageSync(c, r.ID); err != nil {
+			log.Println("Notification error:", err)
+		}
+	}
+	if r.ClubName != "" || r.ClubPic != "" || r.FileURL != "" || r.MaxMembers != nil || r.Tags != nil ||
+		r.BookTitle != "" || r.BookAuthor != "" || r.Genre != "" || r.Language != "" {
+		err = crud.NotifyClubMembers(updated.ID, updated.HostID, models.NotificationClubUpdate, map[string]interface{}{
+			"clubname": updated.ClubName,
+		})
+		if err != nil {
+			log.Println("Notification error:", err)
+		}
+	}
 	return c.JSON(fiber.Map{
 		"status": "success",
 		"data":   updated,
@@ -308,7 +446,7 @@ func togglePrivate(c *fiber.Ctx) error {
 	if err != nil || r == nil {
 		return err
 	}
-	if err := crud.TogglePrivate(r.ID); err != nil {
+	if err := crud.TogglePrivate(r.ID, users.NewAuditLog(c, "toggle_private")); err != nil {
 		return err
 	}
 	return c.JSON(fiber.Map{
@@ -322,42 +460,85 @@ func toggleSync(c *fiber.Ctx) error {
 	if err != nil || r == nil {
 		return err
 	}
-	if err := crud.ToggleSync(r.ID); err != nil {
+	if err := crud.ToggleSync(r.ID, users.NewAuditLog(c, "toggle_sync")); err != nil {
 		return err
 	}
+	if err := notifyPageSync(c, r.ID); err != nil {
+		log.Println("Notification error:", err)
+	}
 	return c.JSON(fiber.Map{
 		"status":  "success",
 		"message": "Club page sync feature has been toggled",
 	})
 }
 
+func toggleArchive(c *fiber.Ctx) error {
+	r, err := prepToggle(c)
+	if err != nil || r == nil {
+		return err
+	}
+	if err := crud.ToggleArchive(r.ID, users.NewAuditLog(c, "toggle_archive")); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Club archive status has been toggled",
+	})
+}
+
+func deleteClub(c *fiber.Ctx) error {
+	r, err := prepToggle(c)
+	if err != nil || r == nil {
+		return err
+	}
+	deleted, err := crud.DeleteClub(r.ID, users.NewAuditLog(c, "delete_club"))
+	if err != nil {
+		return err
+	}
+	for _, fileURL := range []string{deleted.ClubPic, deleted.FileURL} {
+		if err = firebaseclient.DeleteObject(fileURL); err != nil {
+			log.Println("Storage error:", err)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Club has been deleted",
+	})
+}
+
 func kickUser(c *fiber.Ctx) error {
 	r := new(struct {
 		UserID string `json:"user_id"`
 		ClubID string `json:"club_id"`
 	})
 	if err := c.BodyParser(r); err != nil || r.UserID == "" || r.ClubID == "" {
-		return fmt.Errorf("JSON Data Incorrect")
+		return errors.ErrMalformedJSON
 	}
 	clubID := r.ClubID
 	deviantID := r.UserID
 	club, err := crud.GetClub(clubID)
 	if err != nil {
-		return fmt.Errorf("already member")
+		return errors.ErrAlreadyMember
 	}
 	uid, err := users.GetUID(c)
 	if err != nil {
 		return err
 	}
 	if uid != club.HostID {
-		return fmt.Errorf("not host")
+		return errors.ErrNotHost
 	} else if uid == deviantID {
-		return fmt.Errorf("self kick")
+		return errors.ErrSelfKick
 	}
-	_, err = crud.DeleteClubUser(clubID, r.UserID)
+	_, err = crud.RemoveClubUser(clubID, r.UserID, users.NewAuditLog(c, "kick_user"))
 	if err != nil {
 		return err
 	}
+	err = crud.NotifyUser(deviantID, uid, club.ID, models.NotificationKick, map[string]interface{}{
+		"clubname": club.ClubName,
+	})
+	if err != nil {
+		log.Println("Notification error:", err)
+	}
 	return c.JSON(fiber.Map{
 		"status":  "success",
 		"message": "User has been kicked from the club",
@@ -374,11 +555,22 @@ func leaveClub(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	_, err = crud.DeleteClubUser(clubID, uid)
-	if err != nil {
-		if err == pg.ErrNoRows {
-			return fmt.Errorf("not member")
+	_, err = crud.RemoveClubUser(clubID, uid, users.NewAuditLog(c, "leave_club"))
+	if err == pg.ErrNoRows {
+		// Users who are still waiting for a seat leave the waitlist instead
+		err = crud.DeleteClubWaitlist(clubID, uid)
+		if err != nil {
+			if err == pg.ErrNoRows {
+				return errors.ErrNotMember
+			}
+			return err
 		}
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "You have left the club waitlist",
+		})
+	}
+	if err != nil {
 		return err
 	}
 	return c.JSON(fiber.Map{
@@ -387,15 +579,88 @@ func leaveClub(c *fiber.Ctx) error {
 	})
 }
 
-func MountRoutes(app *fiber.App, middleware func(c *fiber.Ctx) error) {
-	authGroup := app.Group("/api/clubs", middleware)
+func muteClub(c *fiber.Ctx) error {
+	r := new(struct {
+		ClubID string `json:"club_id"`
+		Muted  bool   `json:"muted"`
+	})
+	if err := c.BodyParser(r); err != nil || r.ClubID == "" {
+		return errors.ErrMalformedJSON
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	if err = crud.SetClubMute(r.ClubID, uid, r.Muted); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Club push notifications have been updated",
+	})
+}
+
+func setFilterStrictness(c *fiber.Ctx) error {
+	r := new(struct {
+		ClubID     string `json:"club_id"`
+		Strictness string `json:"strictness"`
+	})
+	if err := c.BodyParser(r); err != nil || r.ClubID == "" {
+		return errors.ErrMalformedJSON
+	}
+	if err := prepUpdate(c, r.ClubID); err != nil {
+		return err
+	}
+	if err := crud.SetClubFilterStrictness(r.ClubID, r.Strictness, users.NewAuditLog(c, "set_filter_strictness")); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Club content filter has been updated",
+	})
+}
+
+func getAuditLog(c *fiber.Ctx) error {
+	clubID := c.Query("club_id")
+	if clubID == "" {
+		return errors.ErrMalformedQuery
+	}
+	if err := prepUpdate(c, clubID); err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(c.Query("page", "0"))
+	if err != nil || n < 1 {
+		n = 1
+	}
+	logs, err := crud.ListAuditLog(clubID, n)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   logs,
+	})
+}
+
+func MountRoutes(router fiber.Router, middleware func(c *fiber.Ctx) error) {
+	authGroup := router.Group("/clubs", middleware)
 	authGroup.Get("", getClub)
 	authGroup.Get("list", listClubs)
+	authGroup.Get("discover", discoverClubs)
+	authGroup.Get("trending", listTrendingClubs)
+	authGroup.Get("recommended", listRecommendedClubs)
+	authGroup.Get("categories", listCategories)
+	authGroup.Get("tags", autocompleteTags)
+	authGroup.Get("audit", getAuditLog)
 	authGroup.Post("create", createClub)
 	authGroup.Post("join", joinClub)
 	authGroup.Patch("update", updateClub)
 	authGroup.Post("toggleprivate", togglePrivate)
 	authGroup.Post("togglesync", toggleSync)
+	authGroup.Post("togglearchive", toggleArchive)
+	authGroup.Post("delete", deleteClub)
 	authGroup.Post("kickuser", kickUser)
 	authGroup.Post("leave", leaveClub)
+	authGroup.Post("mute", muteClub)
+	authGroup.Post("filter", setFilterStrictness)
 }
@@ -0,0 +1,128 @@
+package conversations
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/api/endpoints/users"
+	"github.com/Krishap-s/keats-backend/api/ws"
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/Krishap-s/keats-backend/validation"
+)
+
+// Handlers
+
+func listConversations(c *fiber.Ctx) error {
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	conversations, err := crud.ListConversation(uid)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   conversations,
+	})
+}
+
+func startConversation(c *fiber.Ctx) error {
+	r := new(struct {
+		UserID string `json:"user_id"`
+	})
+	if err := c.BodyParser(r); err != nil || r.UserID == "" {
+		return errors.ErrMalformedJSON
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	if _, err = crud.GetPublicUser(r.UserID); err != nil {
+		return errors.ErrUserNotFound
+	}
+	conversation, err := crud.GetOrCreateConversation(uid, r.UserID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   conversation,
+	})
+}
+
+func getMessages(c *fiber.Ctx) error {
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	conversation, err := crud.GetConversation(c.Params("id"), uid)
+	if err != nil {
+		return err
+	}
+	messages, err := crud.GetDirectMessage(conversation.ID.String(), c.Query("before"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   messages,
+	})
+}
+
+func sendMessage(c *fiber.Ctx) error {
+	r := new(schemas.DirectMessageCreate)
+	if err := c.BodyParser(r); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	r.ConversationID = c.Params("id")
+	r.SenderID = uid
+	if err = validation.Validate(r); err != nil {
+		return err
+	}
+	created, err := crud.CreateDirectMessage(r)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   created,
+	})
+}
+
+func markRead(c *fiber.Ctx) error {
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	conversation, err := crud.GetConversation(c.Params("id"), uid)
+	if err != nil {
+		return err
+	}
+	timeRead, err := crud.MarkConversationRead(conversation.ID.String(), uid)
+	if err != nil {
+		return err
+	}
+	if err = ws.PublishRead(conversation, uid, timeRead); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Conversation marked as read",
+	})
+}
+
+// MountRoutes mounts all routes declared here
+func MountRoutes(router fiber.Router, middleware func(c *fiber.Ctx) error) {
+	authGroup := router.Group("/conversations", middleware)
+	authGroup.Get("", listConversations)
+	authGroup.Post("", startConversation)
+	authGroup.Get(":id/messages", getMessages)
+	authGroup.Post(":id/messages", sendMessage)
+	authGroup.Post(":id/read", markRead)
+}
@@ -0,0 +1,41 @@
+package moderation
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/api/endpoints/users"
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/Krishap-s/keats-backend/validation"
+)
+
+// Handlers
+
+func createReport(c *fiber.Ctx) error {
+	r := new(schemas.ReportCreate)
+	if err := c.BodyParser(r); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	if err := validation.Validate(r); err != nil {
+		return err
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	report, err := crud.CreateReport(uid, r)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   report,
+	})
+}
+
+// MountRoutes mounts all routes declared here, reports are reviewed through the admin API
+func MountRoutes(router fiber.Router, middleware func(c *fiber.Ctx) error) {
+	authGroup := router.Group("/reports", middleware)
+	authGroup.Post("", createReport)
+}
@@ -0,0 +1,124 @@
+package notifications
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/api/endpoints/users"
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/Krishap-s/keats-backend/validation"
+)
+
+// Handlers
+
+func listNotifications(c *fiber.Ctx) error {
+	var n int
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	n, err = strconv.Atoi(c.Query("page", "0"))
+	if err != nil || n < 1 {
+		n = 1
+	}
+	notifications, err := crud.ListNotification(uid, n)
+	if err != nil {
+		return err
+	}
+	locale := users.GetLocale(c)
+	for _, notification := range notifications {
+		notification.Title = crud.NotificationTitle(locale, notification.Type)
+	}
+	unread, err := crud.CountUnreadNotification(uid)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   notifications,
+		"unread": unread,
+	})
+}
+
+func unreadCount(c *fiber.Ctx) error {
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	unread, err := crud.CountUnreadNotification(uid)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"unread": unread,
+	})
+}
+
+func markRead(c *fiber.Ctx) error {
+	r := new(schemas.NotificationRead)
+	if err := c.BodyParser(r); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	if err = crud.MarkNotificationRead(uid, r.IDs); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Notifications marked as read",
+	})
+}
+
+func getSettings(c *fiber.Ctx) error {
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	settings, err := crud.GetNotificationSetting(uid)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   settings,
+	})
+}
+
+func updateSettings(c *fiber.Ctx) error {
+	r := new(schemas.NotificationSettingUpdate)
+	if err := c.BodyParser(r); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	if err := validation.Validate(r); err != nil {
+		return err
+	}
+	uid, err := users.GetUID(c)
+	if err != nil {
+		return err
+	}
+	setting, err := crud.UpdateNotificationSetting(uid, r)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   setting,
+	})
+}
+
+// MountRoutes mounts all routes declared here
+func MountRoutes(router fiber.Router, middleware func(c *fiber.Ctx) error) {
+	authGroup := router.Group("/notifications", middleware)
+	authGroup.Get("", listNotifications)
+	authGroup.Get("unread", unreadCount)
+	authGroup.Post("read", markRead)
+	authGroup.Get("settings", getSettings)
+	authGroup.Put("settings", updateSettings)
+}
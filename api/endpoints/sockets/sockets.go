@@ -7,89 +7,122 @@ import (
 	"github.com/Krishap-s/keats-backend/api/ws"
 	"github.com/Krishap-s/keats-backend/configs"
 	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/i18n"
+	"github.com/Krishap-s/keats-backend/models"
 	"github.com/form3tech-oss/jwt-go"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/gofiber/websocket/v2"
 	"github.com/google/uuid"
 )
 
-func MountWebsockets(app *fiber.App, middleware func(c *fiber.Ctx) error) {
-	wsRoutes := app.Group("/api/ws", middleware)
+// peerLocale is the locale a peer is answered in, the one its user chose or
+// else the one asked for in the Accept-Language header of the upgrade request
+func peerLocale(conn *websocket.Conn, user *models.User) string {
+	acceptLanguage, _ := conn.Locals("accept_language").(string)
+	preferred := ""
+	if user != nil {
+		preferred = user.Locale
+	}
+	return i18n.Negotiate(preferred, acceptLanguage)
+}
+
+// authenticate checks the JWT passed in the token query parameter and returns
+// its user and the locale they read, telling the peer what went wrong otherwise
+func authenticate(conn *websocket.Conn) (*models.User, string, bool) {
+	locale := peerLocale(conn, nil)
+	tokenstring := conn.Query("token")
+	token, err := jwt.Parse(tokenstring, func(token *jwt.Token) (interface{}, error) {
+		// Don't forget to validate the alg is what you expect:
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+
+		// hmacSampleSecret is a []byte containing your secret, e.g. []byte("my_secret_key")
+		return []byte(configs.GetSecret()), nil
+	})
+	if err != nil {
+		if err.Error() == "Missing or malformed JWT" {
+			err = conn.WriteJSON(errors.Frame(errors.ErrMalformedJWT, locale))
+			log.Println("Websocket error:", err)
+			return nil, "", false
+		}
+		err = conn.WriteJSON(errors.Frame(errors.ErrInvalidJWT, locale))
+		log.Println("Websocket error:", err)
+		return nil, "", false
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	uid, _ := claims["id"].(string)
+	var user *models.User
+	_, err = uuid.Parse(uid)
+	if err == nil {
+		user, err = crud.GetUser(uid)
+		if err == nil && (!configs.TokenIsCurrent(claims, user) || user.IsSuspended()) {
+			err = errors.ErrInvalidJWT
+		}
+		if err == nil {
+			locale = peerLocale(conn, user)
+		}
+	}
+	if err != nil {
+		err = conn.WriteJSON(errors.Frame(errors.ErrInvalidJWT, locale))
+		log.Println("Websocket error:", err)
+		return nil, "", false
+	}
+	return user, locale, true
+}
+
+// protocols negotiates the version of the websocket protocol on upgrade
+var protocols = websocket.Config{Subprotocols: ws.Protocols}
+
+func MountWebsockets(router fiber.Router, middleware func(c *fiber.Ctx) error) {
+	wsRoutes := router.Group("/ws", middleware)
 	wsRoutes.Use("", func(c *fiber.Ctx) error {
 		// IsWebSocketUpgrade returns true if the client
 		// requested upgrade to the WebSocket protocol.
 		if websocket.IsWebSocketUpgrade(c) {
 			c.Locals("allowed", true)
+			// Headers are not kept past the upgrade so the one needed is copied
+			c.Locals("accept_language", utils.CopyString(c.Get(fiber.HeaderAcceptLanguage)))
 			return c.Next()
 		}
 		return fiber.ErrUpgradeRequired
 	})
+	wsRoutes.Get("me", websocket.New(func(conn *websocket.Conn) {
+		user, locale, ok := authenticate(conn)
+		if !ok {
+			return
+		}
+		ws.ServeUserWs(conn, user.ID.String(), user.TokenVersion, locale)
+	}, protocols))
 	wsRoutes.Get(":id", websocket.New(func(conn *websocket.Conn) {
 		clubID := conn.Params("id")
 		_, err := crud.GetClub(clubID)
 		if err != nil {
-			err = conn.WriteJSON(fiber.Map{
-				"action":  "error",
-				"message": "Club not found",
-			})
+			err = conn.WriteJSON(errors.Frame(errors.ErrClubNotFound, peerLocale(conn, nil)))
 			log.Println("Websocket error:", err)
 			return
 		}
 		usersList, err := crud.GetClubUser(clubID)
 		log.Println("DB error:", err)
-		tokenstring := conn.Query("token")
-		var token *jwt.Token
-		token, err = jwt.Parse(tokenstring, func(token *jwt.Token) (interface{}, error) {
-			// Don't forget to validate the alg is what you expect:
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-			}
-
-			// hmacSampleSecret is a []byte containing your secret, e.g. []byte("my_secret_key")
-			return []byte(configs.GetSecret()), nil
-		})
-		if err != nil {
-			if err.Error() == "Missing or malformed JWT" {
-				err = conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": "Missing or malformed JWT",
-				})
-				log.Println("Websocket error:", err)
-				return
-			}
-			err = conn.WriteJSON(fiber.Map{
-				"action":  "error",
-				"message": "Invalid or Expired JWT",
-			})
-			log.Println("Websocket error:", err)
-			return
-		}
-		claims := token.Claims.(jwt.MapClaims)
-		uid := claims["id"].(string)
-		userID, err := uuid.Parse(uid)
-		if err != nil {
-			err = conn.WriteJSON(fiber.Map{
-				"action":  "error",
-				"message": "Invalid or Expired JWT",
-			})
-			log.Println("Websocket error:", err)
+		user, locale, ok := authenticate(conn)
+		if !ok {
 			return
 		}
+		uid := user.ID.String()
 		var isMember = false
 		for _, clubUser := range usersList {
-			if clubUser.ID == userID {
+			if clubUser.ID == uid {
 				isMember = true
 				break
 			}
 		}
 		if !isMember {
-			err = conn.WriteJSON(fiber.Map{
-				"action":  "error",
-				"message": "You are not a member of this club",
-			})
+			err = conn.WriteJSON(errors.Frame(errors.ErrNotMember, locale))
 			log.Println("Websocket error:", err)
 			return
 		}
-		ws.ServeWs(conn, uid, clubID)
-	}))
+		ws.ServeWs(conn, uid, user.TokenVersion, clubID, locale)
+	}, protocols))
 }
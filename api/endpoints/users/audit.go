@@ -0,0 +1,21 @@
+package users
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/models"
+)
+
+// NewAuditLog starts an audit log entry for an action taken by the user of the request
+func NewAuditLog(c *fiber.Ctx, action string) *models.AuditLog {
+	audit := &models.AuditLog{
+		Action: action,
+	}
+	if user, ok := c.Locals("user").(*models.User); ok {
+		audit.ActorID = user.ID
+	}
+	if requestID, ok := c.Locals("requestid").(string); ok {
+		audit.RequestID = requestID
+	}
+	return audit
+}
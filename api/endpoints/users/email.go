@@ -0,0 +1,210 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/mailer"
+	"github.com/Krishap-s/keats-backend/redisclient"
+	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/Krishap-s/keats-backend/validation"
+)
+
+const (
+	// Time a one-time email code stays valid
+	emailCodeTTL = 10 * time.Minute
+
+	// Maximum number of codes sent to one email per hour
+	maxEmailCodes = 5
+
+	// Maximum number of wrong guesses before a code is thrown away
+	maxEmailCodeAttempts = 5
+)
+
+// Non Handlers
+
+func emailCodeKey(purpose string, email string) string {
+	return "email_code_" + purpose + "_" + email
+}
+
+func generateEmailCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// sendEmailCode emails a one-time code for purpose, limiting how often codes are sent
+func sendEmailCode(ctx context.Context, purpose string, email string) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	key := emailCodeKey(purpose, email)
+	pipe := rdb.TxPipeline()
+	count := pipe.Incr(ctx, key+"_sent")
+	pipe.Expire(ctx, key+"_sent", time.Hour)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return err
+	}
+	if count.Val() > maxEmailCodes {
+		return errors.ErrMaxEmailCodes
+	}
+	code, err := generateEmailCode()
+	if err != nil {
+		return err
+	}
+	pipe = rdb.TxPipeline()
+	pipe.Set(ctx, key, code, emailCodeTTL)
+	pipe.Del(ctx, key+"_attempts")
+	if _, err = pipe.Exec(ctx); err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Your Keats code is %s. It expires in %d minutes.", code, int(emailCodeTTL.Minutes()))
+	return mailer.GetMailer().Send(email, "Your Keats code", body)
+}
+
+// checkEmailCode redeems a one-time code sent for purpose
+func checkEmailCode(ctx context.Context, purpose string, email string, code string) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	key := emailCodeKey(purpose, email)
+	attempts, err := rdb.Incr(ctx, key+"_attempts").Result()
+	if err != nil {
+		return err
+	}
+	rdb.Expire(ctx, key+"_attempts", emailCodeTTL)
+	expected, err := rdb.Get(ctx, key).Result()
+	if err != nil || attempts > maxEmailCodeAttempts || code == "" || code != expected {
+		if attempts > maxEmailCodeAttempts {
+			rdb.Del(ctx, key)
+		}
+		return errors.ErrInvalidEmailCode
+	}
+	rdb.Del(ctx, key, key+"_attempts")
+	return nil
+}
+
+func parseEmailCodeRequest(c *fiber.Ctx) (string, error) {
+	r := new(schemas.EmailCodeRequest)
+	if err := c.BodyParser(r); err != nil {
+		return "", errors.ErrMalformedJSON
+	}
+	if err := validation.Validate(r); err != nil {
+		return "", err
+	}
+	return crud.NormalizeEmail(r.Email)
+}
+
+func parseEmailCodeVerify(c *fiber.Ctx) (string, string, error) {
+	r := new(schemas.EmailCodeVerify)
+	if err := c.BodyParser(r); err != nil {
+		return "", "", errors.ErrMalformedJSON
+	}
+	if err := validation.Validate(r); err != nil {
+		return "", "", err
+	}
+	email, err := crud.NormalizeEmail(r.Email)
+	if err != nil {
+		return "", "", err
+	}
+	return email, r.Code, nil
+}
+
+// Handlers
+
+func requestLoginCode(c *fiber.Ctx) error {
+	email, err := parseEmailCodeRequest(c)
+	if err != nil {
+		return err
+	}
+	if err = sendEmailCode(c.Context(), "login", email); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Code sent",
+	})
+}
+
+func loginWithEmail(c *fiber.Ctx) error {
+	email, code, err := parseEmailCodeVerify(c)
+	if err != nil {
+		return err
+	}
+	if err = checkEmailCode(c.Context(), "login", email, code); err != nil {
+		return err
+	}
+	user, err := crud.GetUserByEmail(email)
+	if err == pg.ErrNoRows {
+		user, err = crud.CreateEmailUser(email)
+	}
+	if err != nil {
+		return err
+	}
+
+	signedToken, err := createJWT(user)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"token":   signedToken,
+			"user_id": user.ID,
+		},
+	})
+}
+
+func requestVerifyCode(c *fiber.Ctx) error {
+	email, err := parseEmailCodeRequest(c)
+	if err != nil {
+		return err
+	}
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	if err = sendEmailCode(c.Context(), "verify_"+uid, email); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Code sent",
+	})
+}
+
+func verifyEmail(c *fiber.Ctx) error {
+	email, code, err := parseEmailCodeVerify(c)
+	if err != nil {
+		return err
+	}
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	if err = checkEmailCode(c.Context(), "verify_"+uid, email, code); err != nil {
+		return err
+	}
+	updated, err := crud.VerifyUserEmail(uid, email)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"data":    updated,
+		"message": "Email verified",
+	})
+}
@@ -0,0 +1,146 @@
+package users
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/mailer"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/redisclient"
+	"github.com/Krishap-s/keats-backend/sms"
+)
+
+// Time a verified phone number change waits to be confirmed
+const phoneChangeTTL = 10 * time.Minute
+
+// Non Handlers
+
+func phoneChangeKey(uid string, changeID string) string {
+	return "phone_change_" + uid + "_" + changeID
+}
+
+// maskPhoneNo hides all but the last four digits of a phone number
+func maskPhoneNo(phoneNo string) string {
+	if len(phoneNo) <= 4 {
+		return phoneNo
+	}
+	masked := make([]byte, len(phoneNo))
+	for i := range masked {
+		masked[i] = '*'
+	}
+	copy(masked[len(masked)-4:], phoneNo[len(phoneNo)-4:])
+	return string(masked)
+}
+
+// notifyPhoneNoChange tells the owner of the old phone number, and their
+// verified email if any, that the phone number of their account was changed
+func notifyPhoneNoChange(user *models.User, oldPhoneNo string) {
+	body := fmt.Sprintf("The phone number of your Keats account was changed to %s. If this was not you, contact support.", maskPhoneNo(user.PhoneNo))
+	if oldPhoneNo != "" && sms.GetSender() != nil {
+		if err := sms.GetSender().Send(oldPhoneNo, body); err != nil {
+			log.Println("SMS error:", err)
+		}
+	}
+	if user.Email != "" && user.EmailVerified {
+		if err := mailer.GetMailer().Send(user.Email, "Your phone number was changed", body); err != nil {
+			log.Println("Mail error:", err)
+		}
+	}
+}
+
+// Handlers
+
+// startPhoneNoChange verifies the new phone number through its ID token and
+// holds the change until it is confirmed
+func startPhoneNoChange(c *fiber.Ctx) error {
+	phoneNumber, err := getPhoneNo(c)
+	if err != nil {
+		return err
+	}
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	taken, err := crud.IsPhoneNoTaken(phoneNumber)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errors.ErrPhoneNoExists
+	}
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	changeID := uuid.NewString()
+	err = rdb.Set(c.Context(), phoneChangeKey(uid, changeID), phoneNumber, phoneChangeTTL).Err()
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"change_id":    changeID,
+			"phone_number": phoneNumber,
+		},
+		"message": "Phone number verified, confirm the change to apply it",
+	})
+}
+
+// confirmPhoneNoChange applies a verified phone number change, signs out every
+// other session and notifies the old phone number
+func confirmPhoneNoChange(c *fiber.Ctx) error {
+	r := new(struct {
+		ChangeID string `json:"change_id"`
+	})
+	if err := c.BodyParser(r); err != nil || r.ChangeID == "" {
+		return errors.ErrMalformedJSON
+	}
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	// The old phone number has to be told of the change
+	if user, ok := c.Locals("user").(*models.User); ok && user.PhoneNo != "" && sms.GetSender() == nil {
+		return errors.ErrSMSUnavailable
+	}
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	key := phoneChangeKey(uid, r.ChangeID)
+	phoneNumber, err := rdb.Get(c.Context(), key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return errors.ErrPhoneChangeNotFound
+		}
+		return err
+	}
+	updated, oldPhoneNo, err := crud.ChangeUserPhoneNo(uid, phoneNumber)
+	if err != nil {
+		return err
+	}
+	rdb.Del(c.Context(), key)
+	notifyPhoneNoChange(updated, oldPhoneNo)
+
+	// Existing sessions were invalidated, so this one gets a fresh token
+	signedToken, err := createJWT(updated)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"token":   signedToken,
+			"user_id": updated.ID,
+		},
+		"message": "Phone number updated",
+	})
+}
@@ -1,17 +1,25 @@
 package users
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
-	"fmt"
+	"encoding/json"
+	"io"
+	"log"
 	"mime/multipart"
 	"strconv"
+	"strings"
 
 	"github.com/Krishap-s/keats-backend/configs"
 	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
 	"github.com/Krishap-s/keats-backend/firebaseclient"
+	"github.com/Krishap-s/keats-backend/i18n"
 	"github.com/Krishap-s/keats-backend/models"
 	"github.com/Krishap-s/keats-backend/schemas"
 	"github.com/Krishap-s/keats-backend/utils"
+	"github.com/Krishap-s/keats-backend/validation"
 	jwt "github.com/form3tech-oss/jwt-go"
 	"github.com/go-pg/pg/v10"
 	"github.com/gofiber/fiber/v2"
@@ -29,6 +37,13 @@ func GetUID(c *fiber.Ctx) (string, error) {
 	return uid, nil
 }
 
+// GetLocale returns the locale to answer a request in, the one its user chose
+// or else the one asked for by the client
+func GetLocale(c *fiber.Ctx) string {
+	preferred, _ := c.Locals("locale").(string)
+	return i18n.Negotiate(preferred, c.Get(fiber.HeaderAcceptLanguage))
+}
+
 type IDTokenRequest struct {
 	IDToken string `json:"id_token"`
 }
@@ -41,23 +56,27 @@ func getPhoneNo(c *fiber.Ctx) (string, error) {
 	}
 	err = c.BodyParser(req)
 	if err != nil {
-		return "", fmt.Errorf("malformed IDToken")
+		return "", errors.ErrMalformedIDToken
 	}
 	fireToken, err := client.VerifyIDToken(context.Background(), req.IDToken)
 	if err != nil {
-		return "", fmt.Errorf("IDToken verification failed")
+		return "", errors.ErrIDTokenVerification
 	}
 	phoneNumber, ok := fireToken.Claims["phone_number"].(string)
 	if !ok {
-		return "", fmt.Errorf("no phoneNo")
+		return "", errors.ErrNoPhoneNo
 	}
 	return phoneNumber, nil
 }
 
 func createJWT(user *models.User) (string, error) {
+	if user.IsSuspended() {
+		return "", errors.ErrSuspended
+	}
 	token := jwt.New(jwt.SigningMethodHS256)
 	claims := token.Claims.(jwt.MapClaims)
 	claims["id"] = user.ID
+	claims["ver"] = user.TokenVersion
 	signedToken, err := token.SignedString([]byte(configs.GetSecret()))
 	if err != nil {
 		return "", err
@@ -75,6 +94,9 @@ func createUser(c *fiber.Ctx) error {
 	u := &schemas.UserCreate{
 		PhoneNo: phoneNumber,
 	}
+	if err = validation.Validate(u); err != nil {
+		return err
+	}
 
 	created, err := crud.CreateUser(u)
 	if err != nil {
@@ -98,23 +120,29 @@ func createUser(c *fiber.Ctx) error {
 func updateUser(c *fiber.Ctx) error {
 	r := new(schemas.UserUpdate)
 	if err := c.BodyParser(r); err != nil {
-		return fmt.Errorf("JSON Data Incorrect")
+		return errors.ErrMalformedJSON
 	}
 	uid, err := GetUID(c)
 	if err != nil {
 		return err
 	}
 	r.ID = uid
+	// Phone numbers and emails can only be changed once verified
+	r.PhoneNo = ""
+	r.Email = ""
+	if err = validation.Validate(r); err != nil {
+		return err
+	}
 	fileHeader, err := c.FormFile("profile_pic")
 	if fileHeader != nil {
 		if err != nil {
-			return fmt.Errorf("form Data Incorrect")
+			return errors.ErrMalformedForm
 		}
 		var file multipart.File
 		file, err = fileHeader.Open()
 		defer utils.CloseFile(file)
 		if err != nil {
-			return fmt.Errorf("file parse error")
+			return errors.ErrFileParse
 		}
 		acceptedTypes := []string{"image/png", "image/jpeg"}
 		r.ProfilePic, err = firebaseclient.WriteObject(&file, acceptedTypes)
@@ -124,6 +152,9 @@ func updateUser(c *fiber.Ctx) error {
 	}
 	updated, err := crud.UpdateUser(r)
 	if err != nil {
+		if pgErr, ok := err.(pg.Error); ok && pgErr.IntegrityViolation() {
+			return errors.ErrHandleExists
+		}
 		return err
 	}
 	return c.JSON(fiber.Map{
@@ -132,73 +163,283 @@ func updateUser(c *fiber.Ctx) error {
 	})
 }
 
-func updateUserPhoneNo(c *fiber.Ctx) error {
-	phoneNumber, err := getPhoneNo(c)
+func getUser(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   c.Locals("user"),
+	})
+}
+
+func getUserClubsAndDetails(c *fiber.Ctx) error {
+	uid, err := GetUID(c)
 	if err != nil {
 		return err
 	}
-	u := &schemas.UserUpdate{
-		PhoneNo: phoneNumber,
+	var n int
+	n, err = strconv.Atoi(c.Query("page", "0"))
+	if err != nil || n < 1 {
+		n = 1
+	}
+	clubs, err := crud.GetUserClub(uid, n)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return errors.ErrClubNotFound
+		}
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"clubs": clubs,
+			"user":  c.Locals("user"),
+		},
+	})
+
+}
+
+func deleteUser(c *fiber.Ctx) error {
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	// Content is anonymized unless the user asks for it to be deleted
+	purge := c.Query("content") == "delete"
+	deleted, err := crud.DeleteUser(uid, purge)
+	if err != nil {
+		return err
+	}
+	if err = firebaseclient.DeleteObject(deleted.ProfilePic); err != nil {
+		log.Println("Storage error:", err)
 	}
-	u.ID, err = GetUID(c)
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": &schemas.UserDelete{
+			ID:         deleted.ID.String(),
+			Username:   deleted.Username,
+			PhoneNo:    deleted.PhoneNo,
+			ProfilePic: deleted.ProfilePic,
+			Email:      deleted.Email,
+			Bio:        deleted.Bio,
+		},
+		"message": "Account deleted",
+	})
+}
+
+func exportUser(c *fiber.Ctx) error {
+	uid, err := GetUID(c)
 	if err != nil {
 		return err
 	}
-	_, err = crud.UpdateUser(u)
+	export, err := crud.ExportUser(uid)
 	if err != nil {
-		pgErr := err.(pg.Error)
-		if pgErr.IntegrityViolation() {
-			return fmt.Errorf("no phoneNo")
+		return err
+	}
+	if c.Query("format") != "zip" {
+		c.Attachment("keats-export.json")
+		return c.JSON(export)
+	}
+
+	// Each part of the export is written to its own file in the archive
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.User},
+		{"memberships.json", export.Memberships},
+		{"chat_messages.json", export.ChatMessages},
+		{"comments.json", export.Comments},
+		{"direct_messages.json", export.DirectMessages},
+	}
+	for _, file := range files {
+		var w io.Writer
+		w, err = archive.Create(file.name)
+		if err != nil {
+			return err
 		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	if err = archive.Close(); err != nil {
+		return err
+	}
+	c.Attachment("keats-export.zip")
+	return c.Send(buf.Bytes())
+}
+
+func parseUserIDRequest(c *fiber.Ctx) (string, error) {
+	r := new(struct {
+		UserID string `json:"user_id"`
+	})
+	if err := c.BodyParser(r); err != nil || r.UserID == "" {
+		return "", errors.ErrMalformedJSON
+	}
+	return r.UserID, nil
+}
+
+func blockUser(c *fiber.Ctx) error {
+	blockedID, err := parseUserIDRequest(c)
+	if err != nil {
+		return err
+	}
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	if _, err = crud.GetPublicUser(blockedID); err != nil {
+		return errors.ErrUserNotFound
+	}
+	if _, err = crud.BlockUser(uid, blockedID); err != nil {
 		return err
 	}
 	return c.JSON(fiber.Map{
 		"status":  "success",
-		"message": "Phone number updated",
+		"message": "User has been blocked",
 	})
+}
 
+func unblockUser(c *fiber.Ctx) error {
+	blockedID, err := parseUserIDRequest(c)
+	if err != nil {
+		return err
+	}
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	if err = crud.UnblockUser(uid, blockedID); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "User has been unblocked",
+	})
 }
 
-func getUser(c *fiber.Ctx) error {
+func listBlockedUsers(c *fiber.Ctx) error {
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	blocked, err := crud.ListBlockedUser(uid)
+	if err != nil {
+		return err
+	}
 	return c.JSON(fiber.Map{
 		"status": "success",
-		"data":   c.Locals("user"),
+		"data":   blocked,
 	})
 }
 
-func getUserClubsAndDetails(c *fiber.Ctx) error {
+func checkHandle(c *fiber.Ctx) error {
+	handle := c.Query("handle")
+	available, err := crud.IsHandleAvailable(handle)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"handle":    strings.ToLower(handle),
+			"available": available,
+		},
+	})
+}
+
+func getUserProfile(c *fiber.Ctx) error {
 	uid, err := GetUID(c)
 	if err != nil {
 		return err
 	}
-	var n int
-	n, err = strconv.Atoi(c.Query("page", "0"))
-	if err != nil || n < 1 {
-		n = 1
+	id := c.Params("id")
+	user, err := crud.GetPublicUser(id)
+	if err != nil {
+		return errors.ErrUserNotFound
 	}
-	clubs, err := crud.GetUserClub(uid, n)
+	stats, err := crud.GetUserStats(user.ID)
+	if err != nil {
+		return err
+	}
+	clubs, err := crud.GetCommonClub(uid, user.ID)
 	if err != nil {
-		if err == pg.ErrNoRows {
-			return fmt.Errorf("club not found")
-		}
 		return err
 	}
 	return c.JSON(fiber.Map{
 		"status": "success",
-		"data": fiber.Map{
-			"clubs": clubs,
-			"user":  c.Locals("user"),
+		"data": &schemas.UserProfile{
+			PublicUser:    *user,
+			ClubsInCommon: clubs,
+			Stats:         *stats,
 		},
 	})
+}
 
+func registerDevice(c *fiber.Ctx) error {
+	r := new(schemas.DeviceTokenCreate)
+	if err := c.BodyParser(r); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	if err := validation.Validate(r); err != nil {
+		return err
+	}
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	if _, err = crud.RegisterDeviceToken(uid, r.Token, r.Platform); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Device has been registered for push notifications",
+	})
+}
+
+func unregisterDevice(c *fiber.Ctx) error {
+	r := new(schemas.DeviceTokenCreate)
+	if err := c.BodyParser(r); err != nil {
+		return errors.ErrMalformedJSON
+	}
+	if err := validation.Validate(r); err != nil {
+		return err
+	}
+	uid, err := GetUID(c)
+	if err != nil {
+		return err
+	}
+	if err = crud.DeleteDeviceToken(uid, r.Token); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Device has been unregistered from push notifications",
+	})
 }
 
 // MountRoutes mounts all routes declared here
-func MountRoutes(app *fiber.App, middleware func(c *fiber.Ctx) error) {
-	app.Post("/api/user", createUser)
-	authGroup := app.Group("/api/user", middleware)
+func MountRoutes(router fiber.Router, middleware func(c *fiber.Ctx) error) {
+	router.Post("/user", createUser)
+	router.Post("/user/email/code", requestLoginCode)
+	router.Post("/user/email/login", loginWithEmail)
+	authGroup := router.Group("/user", middleware)
 	authGroup.Patch("", updateUser)
-	authGroup.Post("updatephone", updateUserPhoneNo)
+	authGroup.Post("updatephone", startPhoneNoChange)
+	authGroup.Post("updatephone/confirm", confirmPhoneNoChange)
 	authGroup.Get("", getUser)
 	authGroup.Get("clubs", getUserClubsAndDetails)
+	authGroup.Delete("", deleteUser)
+	authGroup.Get("export", exportUser)
+	authGroup.Post("email/verify/code", requestVerifyCode)
+	authGroup.Post("email/verify", verifyEmail)
+	authGroup.Get("blocks", listBlockedUsers)
+	authGroup.Post("block", blockUser)
+	authGroup.Post("unblock", unblockUser)
+	authGroup.Get("handle", checkHandle)
+	authGroup.Post("devices", registerDevice)
+	authGroup.Delete("devices", unregisterDevice)
+	authGroup.Get(":id", getUserProfile)
 }
@@ -0,0 +1,66 @@
+// Package server assembles the fiber app serving every version of the REST
+// API and the websockets, shared by the server and its tests.
+package server
+
+//goland:noinspection SpellCheckingInspection
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	jwtware "github.com/gofiber/jwt/v2"
+
+	"github.com/Krishap-s/keats-backend/api/endpoints/admin"
+	"github.com/Krishap-s/keats-backend/api/endpoints/clubs"
+	"github.com/Krishap-s/keats-backend/api/endpoints/conversations"
+	"github.com/Krishap-s/keats-backend/api/endpoints/moderation"
+	"github.com/Krishap-s/keats-backend/api/endpoints/notifications"
+	"github.com/Krishap-s/keats-backend/api/endpoints/sockets"
+	"github.com/Krishap-s/keats-backend/api/endpoints/users"
+	"github.com/Krishap-s/keats-backend/api/versions"
+	"github.com/Krishap-s/keats-backend/asyncapi"
+	"github.com/Krishap-s/keats-backend/configs"
+	"github.com/Krishap-s/keats-backend/openapi"
+)
+
+func healthCheck(c *fiber.Ctx) error {
+	return c.SendString("OK")
+}
+
+// New returns the app with its middleware and every route mounted, configured
+// from viper
+func New() *fiber.App {
+	app := fiber.New(configs.FiberConfig())
+
+	// Use Middleware
+	app.Use(requestid.New(configs.RequestIDConfig()))
+	app.Use(limiter.New(configs.LimiterConfig()))
+	app.Use(logger.New(configs.LoggerConfig()))
+	app.Use(recover.New(configs.RecoverConfig()))
+	app.Use(cors.New(configs.CORSConfig()))
+	// Responses are checked against the spec once brought up to their version
+	app.Use(openapi.Contract(versions.Spec))
+	app.Use(versions.New())
+
+	// Setting up jwt config
+	jwtconf := configs.JWTConfig()
+
+	app.Get("/", healthCheck)
+	app.Get("/api/asyncapi.json", asyncapi.Serve)
+
+	// Every version of the API is served by the same handlers
+	for _, mount := range versions.Mounts {
+		router := app.Group(mount.Prefix)
+		router.Get("/openapi.json", versions.Serve)
+		users.MountRoutes(router, jwtware.New(jwtconf))
+		clubs.MountRoutes(router, jwtware.New(jwtconf))
+		conversations.MountRoutes(router, jwtware.New(jwtconf))
+		notifications.MountRoutes(router, jwtware.New(jwtconf))
+		moderation.MountRoutes(router, jwtware.New(jwtconf))
+		admin.MountRoutes(router, jwtware.New(jwtconf))
+	}
+	sockets.MountWebsockets(app.Group("/api"), jwtware.New(jwtconf))
+	return app
+}
@@ -0,0 +1,303 @@
+// Package servertest runs tests against the server backed by the database
+// and Redis named by the environment, DATABASE_URL, REDIS_ADDRESS and
+// REDIS_PORT, and creates the fixtures they need. Tests needing them are
+// skipped when those cannot be reached.
+package servertest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/configs"
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/redisclient"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+var (
+	configureOnce sync.Once
+	setupOnce     sync.Once
+	// skipReason tells why tests needing the database and Redis are skipped
+	skipReason string
+	setupErr   error
+)
+
+// Configure reads the configuration from the environment, filling in what
+// the server needs to start without sending mail, text messages or pushes
+func Configure() {
+	configureOnce.Do(func() {
+		viper.AutomaticEnv()
+		dir, err := ioutil.TempDir("", "keats-servertest")
+		if err != nil {
+			log.Panic(err)
+		}
+		defaults := map[string]interface{}{
+			"JWT_SECRET":                     "servertest",
+			"MAX_REQUESTS":                   100000,
+			"TIME_PERIOD_IN_MINUTES":         1,
+			"CLUB_PAGE_SIZE":                 20,
+			"MAX_NUMBER_OF_CLUBS_CREATED":    100000,
+			"TIME_PERIOD_CLUB_CREATED_LIMIT": 1,
+			"MAILER":                         "file",
+			"MAIL_LOG_FILE":                  filepath.Join(dir, "mail.log"),
+			"SMS_SENDER":                     "file",
+			"SMS_LOG_FILE":                   filepath.Join(dir, "sms.log"),
+			"PUSH_SENDER":                    "file",
+			"PUSH_LOG_FILE":                  filepath.Join(dir, "push.log"),
+		}
+		for key, value := range defaults {
+			viper.SetDefault(key, value)
+		}
+	})
+}
+
+// Setup configures the server and migrates the test database, skipping the
+// test when the database or Redis cannot be reached
+func Setup(t *testing.T) {
+	t.Helper()
+	Configure()
+	setupOnce.Do(func() {
+		if viper.GetString("DATABASE_URL") == "" {
+			skipReason = "DATABASE_URL is not set"
+			return
+		}
+		if err := pgdb.GetDB().Ping(context.Background()); err != nil {
+			skipReason = "the database cannot be reached: " + err.Error()
+			return
+		}
+		if _, err := redisclient.GetRedisClient(); err != nil {
+			skipReason = "Redis cannot be reached: " + err.Error()
+			return
+		}
+		setupErr = pgdb.Migrate()
+	})
+	if skipReason != "" {
+		t.Skip(skipReason)
+	}
+	if setupErr != nil {
+		t.Fatal("Migrating the test database:", setupErr)
+	}
+}
+
+// Token signs a JWT for user as the server does when they sign in
+func Token(t *testing.T, user *models.User) string {
+	t.Helper()
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["id"] = user.ID
+	claims["ver"] = user.TokenVersion
+	signedToken, err := token.SignedString([]byte(configs.GetSecret()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signedToken
+}
+
+// User creates a user with role signing in with a phone number, deleted along
+// with their content and the clubs they host when the test ends
+func User(t *testing.T, role string) *models.User {
+	t.Helper()
+	user, err := crud.CreateUser(&schemas.UserCreate{
+		PhoneNo: PhoneNo(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanupUser(t, user)
+	if role != models.RoleMember {
+		user.Role = role
+		_, err = pgdb.GetDB().Model(user).Column("role").WherePK().Update()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return user
+}
+
+// EmailUser creates a user signing in with a verified email, deleted along
+// with their content and the clubs they host when the test ends
+func EmailUser(t *testing.T) *models.User {
+	t.Helper()
+	user, err := crud.CreateEmailUser(Email())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanupUser(t, user)
+	return user
+}
+
+// PhoneNo returns a phone number unlikely to be taken by another user
+func PhoneNo() string {
+	return fmt.Sprintf("+1555%07d", uuid.New().ID()%10000000)
+}
+
+// Email returns an email address no user has
+func Email() string {
+	return "st" + strings.ReplaceAll(uuid.NewString(), "-", "") + "@example.com"
+}
+
+func cleanupUser(t *testing.T, user *models.User) {
+	t.Cleanup(func() {
+		var clubIDs []string
+		err := pgdb.GetDB().Model((*models.Club)(nil)).
+			Column("id").
+			Where("host_id = ?", user.ID).
+			Select(&clubIDs)
+		if err != nil {
+			t.Error(err)
+		}
+		for _, clubID := range clubIDs {
+			if _, err = crud.DeleteClub(clubID, nil); err != nil && err != errors.ErrClubNotFound {
+				t.Error(err)
+			}
+		}
+		if _, err = crud.DeleteUser(user.ID.String(), true); err != nil && err != pg.ErrNoRows {
+			t.Error(err)
+		}
+	})
+}
+
+// Club creates a public club hosted by host, deleted when the host is
+func Club(t *testing.T, host *models.User) *models.Club {
+	t.Helper()
+	club, err := crud.CreateClub(&schemas.ClubCreate{
+		ClubName:   "Servertest club",
+		HostID:     host.ID.String(),
+		PageNo:     1,
+		BookTitle:  "Servertest",
+		BookAuthor: "Keats",
+		Language:   "English",
+		Tags:       []string{models.Categories[0]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return club
+}
+
+// Join makes user a member of club
+func Join(t *testing.T, club *models.Club, user *models.User) {
+	t.Helper()
+	if _, err := crud.CreateClubUser(club.ID.String(), user.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ChatMessage sends a chat message from user to club
+func ChatMessage(t *testing.T, club *models.Club, user *models.User) *models.ChatMessage {
+	t.Helper()
+	chatMessage, err := crud.CreateChatMessage(&schemas.ChatMessageCreate{
+		ClubID:  club.ID.String(),
+		UserID:  user.ID.String(),
+		Message: "Servertest chat message",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chatMessage
+}
+
+// Comment comments on the first page of club as user
+func Comment(t *testing.T, club *models.Club, user *models.User) *models.Comment {
+	t.Helper()
+	comment, err := crud.CreateComment(&schemas.CommentCreate{
+		ClubID:   club.ID.String(),
+		ParentID: club.ID.String(),
+		UserID:   user.ID.String(),
+		PageNo:   1,
+		Message:  "Servertest comment",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return comment
+}
+
+// Conversation starts a conversation between user and other with a direct
+// message from user
+func Conversation(t *testing.T, user *models.User, other *models.User) *models.Conversation {
+	t.Helper()
+	conversation, err := crud.GetOrCreateConversation(user.ID.String(), other.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = crud.CreateDirectMessage(&schemas.DirectMessageCreate{
+		ConversationID: conversation.ID.String(),
+		SenderID:       user.ID.String(),
+		Message:        "Servertest direct message",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conversation
+}
+
+// Notification notifies user of a club update by actor
+func Notification(t *testing.T, user *models.User, actor *models.User, club *models.Club) *models.Notification {
+	t.Helper()
+	notification := &models.Notification{
+		UserID:  user.ID,
+		ActorID: actor.ID,
+		ClubID:  club.ID,
+		Type:    models.NotificationClubUpdate,
+		Data:    map[string]interface{}{"clubname": club.ClubName},
+	}
+	if err := crud.CreateNotification(notification); err != nil {
+		t.Fatal(err)
+	}
+	return notification
+}
+
+// Report files a report from reporter on a chat message
+func Report(t *testing.T, reporter *models.User, chatMessage *models.ChatMessage) *models.Report {
+	t.Helper()
+	report, err := crud.CreateReport(reporter.ID.String(), &schemas.ReportCreate{
+		TargetType: models.ReportChatMessage,
+		TargetID:   chatMessage.ID.String(),
+		Reason:     "Servertest report",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+// AuditLog records an action of actor on club in its audit log
+func AuditLog(t *testing.T, club *models.Club, actor *models.User) {
+	t.Helper()
+	audit := &models.AuditLog{
+		ActorID: actor.ID,
+		Action:  "toggle_sync",
+	}
+	if err := crud.ToggleSync(club.ID.String(), audit); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// SetRedis stores value under key in Redis for a minute, as the server does
+// with codes sent to users
+func SetRedis(t *testing.T, key string, value string) {
+	t.Helper()
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rdb.Set(context.Background(), key, value, time.Minute).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,124 @@
+package versions
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/openapi"
+)
+
+// Change is a change made to the shapes of the API in a version. Handlers
+// answer in the shapes of the first version, and responses to requests made
+// to Version or a later one go through every change made up to it.
+type Change struct {
+	Version     int
+	Description string
+	// Components are the schemas of the spec the change applies to
+	Components []string
+	// Schema changes the schema of one of Components
+	Schema func(s *openapi.Schema)
+	// Value changes an object of a response described by one of Components
+	Value func(object map[string]interface{})
+}
+
+// Changes lists the changes of every version, oldest first
+var Changes = []*Change{
+	{
+		Version:     V2,
+		Description: "Clubs nest their host as host, with its id, username and profile_pic, in place of host_id, host_name and host_profile_pic",
+		Components:  []string{"schemas.Club", "schemas.ClubDiscovery", "schemas.ClubRecommendation"},
+		Schema:      nestHostSchema,
+		Value:       nestHost,
+	},
+}
+
+// hostFields maps the fields of the host of a club in the first version to
+// those of host in the second
+var hostFields = [][2]string{
+	{"host_id", "id"},
+	{"host_name", "username"},
+	{"host_profile_pic", "profile_pic"},
+}
+
+func nestHostSchema(s *openapi.Schema) {
+	host := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{}}
+	for _, field := range hostFields {
+		host.Properties[field[1]] = s.Properties[field[0]]
+		host.Required = append(host.Required, field[1])
+		delete(s.Properties, field[0])
+		s.Required = without(s.Required, field[0])
+	}
+	s.Properties["host"] = host
+	s.Required = append(s.Required, "host")
+}
+
+func nestHost(object map[string]interface{}) {
+	host := map[string]interface{}{}
+	for _, field := range hostFields {
+		host[field[1]] = object[field[0]]
+		delete(object, field[0])
+	}
+	object["host"] = host
+}
+
+// changesOf returns the changes made up to version, by the components they
+// apply to
+func changesOf(version int) map[string][]*Change {
+	changes := map[string][]*Change{}
+	for _, change := range Changes {
+		if change.Version > version {
+			continue
+		}
+		for _, component := range change.Components {
+			changes[component] = append(changes[component], change)
+		}
+	}
+	return changes
+}
+
+// upgrade brings a successful JSON response of a handler up to the shapes of
+// the version it was requested in
+func upgrade(c *fiber.Ctx, mount *Mount) error {
+	status := c.Response().StatusCode()
+	contentType := string(c.Response().Header.ContentType())
+	if status < 200 || status > 299 || !strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) {
+		return nil
+	}
+	changes := changesOf(mount.Version)
+	if len(changes) == 0 {
+		return nil
+	}
+	route := c.Route()
+	entry := openapi.Spec().Find(route.Method, strings.TrimPrefix(route.Path, mount.Prefix))
+	if entry == nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(c.Response().Body(), &value); err != nil {
+		return err
+	}
+	schema := entry.Responses["200"].Content[fiber.MIMEApplicationJSON].Schema
+	openapi.Transform(schema, openapi.Spec().Components.Schemas, value, func(component string, object map[string]interface{}) {
+		for _, change := range changes[component] {
+			change.Value(object)
+		}
+	})
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.Response().SetBody(body)
+	return nil
+}
+
+func without(list []string, item string) []string {
+	var kept []string
+	for _, s := range list {
+		if s != item {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,91 @@
+package versions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/openapi"
+)
+
+var (
+	documents     map[*Mount]*openapi.Document
+	documentsJSON map[*Mount][]byte
+	documentsOnce sync.Once
+)
+
+// Specs returns the OpenAPI document of the routes served under each mount,
+// with the changes made up to its version applied
+func Specs() map[*Mount]*openapi.Document {
+	documentsOnce.Do(func() {
+		documents = map[*Mount]*openapi.Document{}
+		documentsJSON = map[*Mount][]byte{}
+		for _, mount := range Mounts {
+			doc := specOf(mount)
+			b, err := json.Marshal(doc)
+			if err != nil {
+				panic(err)
+			}
+			documents[mount] = doc
+			documentsJSON[mount] = b
+		}
+	})
+	return documents
+}
+
+// specOf builds the OpenAPI document of the routes served under mount
+func specOf(mount *Mount) *openapi.Document {
+	doc := openapi.Spec().Clone()
+	doc.Info.Version = fmt.Sprintf("%d.0.0", mount.Version)
+	doc.Servers = []openapi.Server{{URL: mount.Prefix}}
+	for component, changes := range changesOf(mount.Version) {
+		for _, change := range changes {
+			change.Schema(doc.Components.Schemas[component])
+		}
+	}
+	var changes []string
+	for _, change := range Changes {
+		if change.Version <= mount.Version {
+			changes = append(changes, fmt.Sprintf("v%d: %s.", change.Version, change.Description))
+		}
+	}
+	if len(changes) != 0 {
+		doc.Info.Description += " Changes from the first version: " + strings.Join(changes, " ")
+	}
+	if _, ok := deprecated[mount.Version]; ok {
+		doc.Info.Description += fmt.Sprintf(" This version is deprecated, responses carry "+
+			"Deprecation, Sunset and Link headers pointing at its successor under %s.", latestPrefix())
+		for _, methods := range doc.Paths {
+			for _, entry := range methods {
+				entry.Deprecated = true
+			}
+		}
+	}
+	return doc
+}
+
+// Spec returns the OpenAPI document of the version of the API a request was
+// made to and the path of its route relative to the prefix of the version,
+// and a nil document when it is outside of the REST API
+func Spec(c *fiber.Ctx) (*openapi.Document, string) {
+	mount := mountOf(c)
+	if mount == nil {
+		return nil, ""
+	}
+	return Specs()[mount], strings.TrimPrefix(c.Route().Path, mount.Prefix)
+}
+
+// Serve responds with the OpenAPI document of the version of the API it is
+// served under
+func Serve(c *fiber.Ctx) error {
+	mount := mountOf(c)
+	if mount == nil {
+		return fiber.ErrNotFound
+	}
+	Specs()
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(documentsJSON[mount])
+}
@@ -0,0 +1,146 @@
+// Package versions serves the REST API under a prefix per version, telling
+// clients of older versions when they are going away, and brings responses
+// up to the shapes of the version a request was made to.
+package versions
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// Versions of the REST API
+const (
+	V1 = 1
+	V2 = 2
+	// Latest is the version new clients are built against
+	Latest = V2
+)
+
+// Mount is a prefix the REST API is served under
+type Mount struct {
+	Prefix  string
+	Version int
+}
+
+// Mounts lists the prefixes of the REST API, longest first. Routes under /api
+// without a version are those of the first version, kept for the clients
+// built before versions were introduced.
+var Mounts = []*Mount{
+	{Prefix: "/api/v2", Version: V2},
+	{Prefix: "/api/v1", Version: V1},
+	{Prefix: "/api", Version: V1},
+}
+
+// Prefixes returns the prefixes of Mounts
+func Prefixes() []string {
+	prefixes := make([]string, 0, len(Mounts))
+	for _, mount := range Mounts {
+		prefixes = append(prefixes, mount.Prefix)
+	}
+	return prefixes
+}
+
+// unversioned are routes under /api served the same to every client, the
+// websockets negotiating their own version on upgrade
+var unversioned = []string{"/api/asyncapi.json", "/api/ws"}
+
+// deprecated tells when each version older than Latest was deprecated
+var deprecated = map[int]time.Time{
+	V1: time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+}
+
+// match returns the mount a request to path is served under, nil when it is
+// outside of the REST API
+func match(path string) *Mount {
+	for _, prefix := range unversioned {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return nil
+		}
+	}
+	for _, mount := range Mounts {
+		if path == mount.Prefix || strings.HasPrefix(path, mount.Prefix+"/") {
+			return mount
+		}
+	}
+	return nil
+}
+
+// latestPrefix is the prefix the routes of Latest are served under
+func latestPrefix() string {
+	for _, mount := range Mounts {
+		if mount.Version == Latest {
+			return mount.Prefix
+		}
+	}
+	return ""
+}
+
+// sunset returns the date the routes of version stop being served, read from
+// API_V<version>_SUNSET as YYYY-MM-DD, and false when none was planned
+func sunset(version int) (time.Time, bool) {
+	key := fmt.Sprintf("API_V%d_SUNSET", version)
+	value := viper.GetString(key)
+	if value == "" {
+		return time.Time{}, false
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Panicln(fmt.Errorf("%s is not a date: %s", key, err))
+	}
+	return date, true
+}
+
+// New returns a middleware that records the version of the API each request
+// is made to, sets the Deprecation, Sunset and Link headers of RFC 9745 and
+// RFC 8594 on requests to versions older than Latest and brings successful
+// responses up to the shapes of the version
+func New() fiber.Handler {
+	sunsets := map[int]string{}
+	for version := range deprecated {
+		if date, ok := sunset(version); ok {
+			sunsets[version] = date.Format(http.TimeFormat)
+		}
+	}
+	successor := latestPrefix()
+	return func(c *fiber.Ctx) error {
+		mount := match(c.Path())
+		if mount == nil {
+			return c.Next()
+		}
+		c.Locals("api_mount", mount)
+		if date, ok := deprecated[mount.Version]; ok {
+			c.Set("Deprecation", fmt.Sprintf("@%d", date.Unix()))
+			if value, ok := sunsets[mount.Version]; ok {
+				c.Set("Sunset", value)
+			}
+			c.Set(fiber.HeaderLink, fmt.Sprintf(`<%s%s>; rel="successor-version"`,
+				successor, strings.TrimPrefix(c.Path(), mount.Prefix)))
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+		return upgrade(c, mount)
+	}
+}
+
+// mountOf returns the mount a request was served under, nil when it is
+// outside of the REST API
+func mountOf(c *fiber.Ctx) *Mount {
+	mount, _ := c.Locals("api_mount").(*Mount)
+	return mount
+}
+
+// Version returns the version of the API a request was made to, 0 when it is
+// outside of the REST API
+func Version(c *fiber.Ctx) int {
+	if mount := mountOf(c); mount != nil {
+		return mount.Version
+	}
+	return 0
+}
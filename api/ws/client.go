@@ -3,15 +3,18 @@ package ws
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"log"
 	"time"
 
 	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/jobs"
 	"github.com/Krishap-s/keats-backend/models"
 	"github.com/Krishap-s/keats-backend/redisclient"
 	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/Krishap-s/keats-backend/utils"
+	"github.com/Krishap-s/keats-backend/validation"
 	"github.com/go-pg/pg/v10"
 	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
@@ -19,6 +22,19 @@ import (
 	"github.com/google/uuid"
 )
 
+// Versions of the websocket protocol, negotiated as the subprotocol of the
+// upgrade request. Peers offering none speak ProtocolV1.
+const (
+	// ProtocolV1 may batch several messages into one frame, separated by newlines
+	ProtocolV1 = "keats.v1"
+	// ProtocolV2 sends every message in a frame of its own
+	ProtocolV2 = "keats.v2"
+)
+
+// Protocols lists the versions of the websocket protocol the server speaks,
+// preferred first
+var Protocols = []string{ProtocolV2, ProtocolV1}
+
 const (
 	// Time allowed to write a message to the peer.
 	writeWait = 10 * time.Second
@@ -31,6 +47,9 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 512
+
+	// Time after which the users blocked by a client are reloaded.
+	blockRefreshPeriod = 30 * time.Second
 )
 
 var (
@@ -45,9 +64,18 @@ type Client struct {
 	// Redis PubSub channel
 	PubSub *redis.PubSub
 
-	// Channel the client is subscribed to
+	// Club the client is subscribed to, empty for personal websockets
 	ClubID string
 
+	// Locale errors are reported to the client in
+	Locale string
+
+	// Version of the websocket protocol spoken with the client
+	Protocol string
+
+	// Version of the tokens of the user when the client connected
+	TokenVersion int
+
 	// The websocket connection.
 	conn *websocket.Conn
 
@@ -56,6 +84,154 @@ type Client struct {
 
 	// Kill switch channel to synchronise closing of both readPump and writePump
 	killChannel chan bool
+
+	// Users blocked by the client, only accessed from writePump
+	blocked map[string]bool
+
+	// Time blocked was last loaded from the database
+	blockedLoaded time.Time
+}
+
+// validate checks a request against the rules of its schema and tells the
+// peer which fields are wrong when it fails
+func (c *Client) validate(v interface{}) bool {
+	errs := validation.Validate(v)
+	if errs == nil {
+		return true
+	}
+	err := c.conn.WriteJSON(errors.Frame(errs, c.Locale))
+	log.Println("Websocket error:", err)
+	return false
+}
+
+// publishMentions sends a mention event carrying data to every member of the
+// club mentioned in text, and notifies them with notificationData along with
+// the text and the name of the club.
+//
+// Mention events are addressed "to" a single user and are dropped by the
+// writePump of every other client subscribed to the club.
+func (c *Client) publishMentions(rdb *redis.Client, text string, data fiber.Map, notificationData map[string]interface{}) {
+	handles := utils.ParseMentions(text)
+	if len(handles) == 0 {
+		return
+	}
+	mentioned, err := crud.GetClubUserByHandle(c.ClubID, c.UserID, handles)
+	if err != nil {
+		log.Println("Websocket error:", err)
+		return
+	}
+	if len(mentioned) == 0 {
+		return
+	}
+	notificationData["message"] = text
+	club, err := crud.GetClub(c.ClubID)
+	if err != nil {
+		log.Println("Websocket error:", err)
+		return
+	}
+	notificationData["clubname"] = club.ClubName
+	ctx := context.Background()
+	for _, user := range mentioned {
+		if user.ID == c.UserID {
+			continue
+		}
+		var enabled bool
+		enabled, err = crud.NotificationEnabled(user.ID, c.ClubID, models.NotificationMention)
+		if err != nil || !enabled {
+			continue
+		}
+		var byteMessage []byte
+		byteMessage, err = json.Marshal(fiber.Map{
+			"user_id": c.UserID,
+			"action":  "mention",
+			"to":      user.ID,
+			"data":    data,
+		})
+		if err != nil {
+			log.Println("Websocket error:", err)
+			continue
+		}
+		rdb.Publish(ctx, c.ClubID, byteMessage)
+		err = crud.NotifyUser(user.ID, c.UserID, c.ClubID, models.NotificationMention, notificationData)
+		if err != nil {
+			log.Println("Notification error:", err)
+		}
+	}
+}
+
+// handleReport files a report on content of the club into the moderation queue
+// and tells only the reporting client how it went
+func (c *Client) handleReport(jsonMessage map[string]interface{}) {
+	reportJSON, err := json.Marshal(jsonMessage["data"])
+	log.Println("Websocket error:", err)
+	var report schemas.ReportCreate
+	err = json.Unmarshal(reportJSON, &report)
+	if err != nil || (report.TargetType != models.ReportChatMessage && report.TargetType != models.ReportComment) {
+		err = c.conn.WriteJSON(errors.Frame(errors.ErrMalformedJSON, c.Locale))
+		log.Println("Websocket error:", err)
+		return
+	}
+	if !c.validate(&report) {
+		return
+	}
+	var created *models.Report
+	created, err = crud.CreateReport(c.UserID, &report)
+	if err != nil {
+		err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
+		log.Println("Websocket error:", err)
+		return
+	}
+	err = c.conn.WriteJSON(fiber.Map{
+		"action": "report",
+		"data":   created,
+	})
+	log.Println("Websocket error:", err)
+}
+
+// sessionError tells why the session of the client has ended since it
+// connected, its user having been suspended or signed out everywhere
+func (c *Client) sessionError() error {
+	user, err := crud.GetUser(c.UserID)
+	if err != nil {
+		return err
+	}
+	if user.TokenVersion != c.TokenVersion {
+		return errors.ErrInvalidJWT
+	}
+	if user.IsSuspended() {
+		return errors.ErrSuspended
+	}
+	return nil
+}
+
+// isBlockedSender reports whether a published message was sent by a user this client has blocked
+func (c *Client) isBlockedSender(message map[string]interface{}) bool {
+	sender, ok := message["user_id"].(string)
+	if !ok {
+		return false
+	}
+	if time.Since(c.blockedLoaded) > blockRefreshPeriod {
+		ids, err := crud.ListBlockedID(c.UserID)
+		if err != nil {
+			log.Println("Websocket error:", err)
+		} else {
+			c.blocked = make(map[string]bool, len(ids))
+			for _, id := range ids {
+				c.blocked[id] = true
+			}
+			c.blockedLoaded = time.Now()
+		}
+	}
+	return c.blocked[sender]
+}
+
+// isRecipient reports whether a published message is meant for this client.
+// Messages addressed "to" another user and messages from blocked users are dropped
+func (c *Client) isRecipient(message map[string]interface{}) bool {
+	if to, ok := message["to"].(string); ok && to != c.UserID {
+		return false
+	}
+	return !c.isBlockedSender(message)
 }
 
 // readPump pumps messages from the websocket connection to the pubsub channel.
@@ -78,19 +254,42 @@ func (c *Client) readPump(rdb *redis.Client) {
 				log.Printf("error: %v", err)
 				break
 			}
-			err = c.conn.WriteJSON(fiber.Map{
-				"status":  "error",
-				"message": "Invalid message format",
-			})
+			err = c.conn.WriteJSON(errors.Frame(errors.ErrMalformedJSON, c.Locale))
 			log.Println("Websocket error:", err)
 			continue
 		}
 
 		if jsonMessage["action"] == "" {
-			err = c.conn.WriteJSON(fiber.Map{
-				"status":  "error",
-				"message": "Invalid message format",
-			})
+			err = c.conn.WriteJSON(errors.Frame(errors.ErrMalformedJSON, c.Locale))
+			log.Println("Websocket error:", err)
+			continue
+		}
+		// Clients of suspended users and ended sessions are disconnected
+		// before anything more they send is persisted
+		if err = c.sessionError(); err != nil {
+			err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
+			log.Println("Websocket error:", err)
+			break
+		}
+		// Personal websockets have their own set of actions
+		if c.ClubID == "" {
+			c.handleUserAction(rdb, jsonMessage)
+			continue
+		}
+		// Reports go to moderators only and stay possible in archived clubs
+		if jsonMessage["action"] == "report" {
+			c.handleReport(jsonMessage)
+			continue
+		}
+
+		// Archived clubs are read-only
+		var archived bool
+		archived, err = crud.IsClubArchived(c.ClubID)
+		if err != nil || archived {
+			if err == nil {
+				err = errors.ErrClubArchived
+			}
+			err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
 			log.Println("Websocket error:", err)
 			continue
 		}
@@ -98,10 +297,7 @@ func (c *Client) readPump(rdb *redis.Client) {
 		case "chatmessage":
 			text, ok := jsonMessage["data"].(string)
 			if !ok {
-				err = c.conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": "data in incorrect format",
-				})
+				err = c.conn.WriteJSON(errors.Frame(errors.ErrMalformedJSON, c.Locale))
 				log.Println("Websocket error:", err)
 				continue
 			}
@@ -112,13 +308,13 @@ func (c *Client) readPump(rdb *redis.Client) {
 				Message: text,
 				Likes:   0,
 			}
+			if !c.validate(chatmessage) {
+				continue
+			}
 			var createdchatmessage *models.ChatMessage
 			createdchatmessage, err = crud.CreateChatMessage(chatmessage)
 			if err != nil {
-				err = c.conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": "Something went wrong",
-				})
+				err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
 				log.Println("Websocket error:", err)
 				continue
 			}
@@ -127,14 +323,19 @@ func (c *Client) readPump(rdb *redis.Client) {
 				"action":  "chatmessage",
 				"data":    createdchatmessage,
 			}
+			c.publishMentions(rdb, createdchatmessage.Message, fiber.Map{
+				"type":        "chatmessage",
+				"chatmessage": createdchatmessage,
+			}, map[string]interface{}{
+				"type":           "chatmessage",
+				"chatmessage_id": createdchatmessage.ID,
+			})
+			jobs.QueueChatPush(c.ClubID, c.UserID, createdchatmessage.Message)
 		case "like_chatmessage":
 			id, ok := jsonMessage["data"].(string)
 			_, err = uuid.Parse(id)
 			if !ok || err != nil {
-				err = c.conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": "data in incorrect format",
-				})
+				err = c.conn.WriteJSON(errors.Frame(errors.ErrMalformedJSON, c.Locale))
 				log.Println("Websocket error:", err)
 				continue
 			}
@@ -147,17 +348,11 @@ func (c *Client) readPump(rdb *redis.Client) {
 			err = crud.AddChatMessageLike(id)
 			if err != nil {
 				if err == pg.ErrNoRows {
-					err = c.conn.WriteJSON(fiber.Map{
-						"action":  "error",
-						"message": "Chatmessage not found",
-					})
+					err = c.conn.WriteJSON(errors.Frame(errors.ErrChatMessageNotFound, c.Locale))
 					log.Println("Websocket error:", err)
 					continue
 				}
-				err = c.conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": "Something went wrong",
-				})
+				err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
 				log.Println("Websocket error:", err)
 				continue
 			}
@@ -167,23 +362,20 @@ func (c *Client) readPump(rdb *redis.Client) {
 			log.Println("Websocket error:", err)
 			var comment schemas.CommentCreate
 			err = json.Unmarshal(commentJSON, &comment)
-			if err != nil || comment.Message == "" || comment.PageNo == 0 {
-				err = c.conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": "data in incorrect format",
-				})
+			if err != nil {
+				err = c.conn.WriteJSON(errors.Frame(errors.ErrMalformedJSON, c.Locale))
 				log.Println("Websocket error:", err)
 				continue
 			}
 			comment.UserID = c.UserID
 			comment.ClubID = c.ClubID
+			if !c.validate(&comment) {
+				continue
+			}
 			var createdcomment *models.Comment
 			createdcomment, err = crud.CreateComment(&comment)
 			if err != nil {
-				err = c.conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": err.Error(),
-				})
+				err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
 				log.Println("Websocket error:", err)
 				continue
 			}
@@ -192,14 +384,23 @@ func (c *Client) readPump(rdb *redis.Client) {
 				"action":  "comment",
 				"data":    createdcomment,
 			}
+			c.publishMentions(rdb, createdcomment.Message, fiber.Map{
+				"type":    "comment",
+				"comment": createdcomment,
+			}, map[string]interface{}{
+				"type":       "comment",
+				"comment_id": createdcomment.ID,
+				"parent_id":  createdcomment.ParentID,
+				"page_no":    createdcomment.PageNo,
+			})
+			if err = crud.NotifyCommentReply(createdcomment); err != nil {
+				log.Println("Notification error:", err)
+			}
 		case "like_comment":
 			id, ok := jsonMessage["data"].(string)
 			_, err = uuid.Parse(id)
 			if !ok || err != nil {
-				err = c.conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": "data in incorrect format",
-				})
+				err = c.conn.WriteJSON(errors.Frame(errors.ErrMalformedJSON, c.Locale))
 				log.Println("Websocket error:", err)
 				continue
 			}
@@ -212,26 +413,18 @@ func (c *Client) readPump(rdb *redis.Client) {
 			err = crud.AddCommentLike(id)
 			if err != nil {
 				if err == pg.ErrNoRows {
-					err = c.conn.WriteJSON(fiber.Map{
-						"action":  "error",
-						"message": "Comment not found",
-					})
+					err = c.conn.WriteJSON(errors.Frame(errors.ErrCommentNotFound, c.Locale))
 					log.Println("Websocket error:", err)
 					continue
 				}
-				err = c.conn.WriteJSON(fiber.Map{
-					"action":  "error",
-					"message": "Something went wrong",
-				})
+				err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
 				log.Println("Websocket error:", err)
 				continue
 			}
 		default:
-			err = c.conn.WriteJSON(fiber.Map{
-				"status":  "error",
-				"message": fmt.Sprint(jsonMessage["action"], " is not an action"),
-			})
+			err = c.conn.WriteJSON(errors.Frame(errors.ErrUnknownAction, c.Locale))
 			log.Println("Websocket error:", err)
+			continue
 		}
 		var bytePublishMessage []byte
 		bytePublishMessage, err = json.Marshal(publishMessage)
@@ -260,7 +453,13 @@ func (c *Client) writePump() {
 				// The pubsub closed the ClubID.
 				break
 			}
-			err := c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			byteMessage := []byte(message.Payload)
+			err := json.Unmarshal(byteMessage, &jsonMessage)
+			log.Println("Websocket error:", err)
+			if !c.isRecipient(jsonMessage) {
+				continue
+			}
+			err = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			log.Println("Websocket error:", err)
 
 			var w io.WriteCloser
@@ -268,18 +467,24 @@ func (c *Client) writePump() {
 			if err != nil {
 				break
 			}
-			byteMessage := []byte(message.Payload)
-			err = json.Unmarshal(byteMessage, &jsonMessage)
-			log.Println("Websocket error:", err)
-			_, err = w.Write([]byte(message.Payload))
+			_, err = w.Write(byteMessage)
 			log.Println("Websocket error:", err)
 
-			// Add queued chat messages to the current websocket message.
+			// Add queued chat messages to the current websocket message, the
+			// second version of the protocol sends them in frames of their own.
 			n := len(c.send)
+			if c.Protocol == ProtocolV2 {
+				n = 0
+			}
 			for i := 0; i < n; i++ {
+				queued := <-c.send
+				var jsonQueued map[string]interface{}
+				if json.Unmarshal([]byte(queued.Payload), &jsonQueued) == nil && !c.isRecipient(jsonQueued) {
+					continue
+				}
 				_, err = w.Write(newline)
 				log.Println("Websocket error:", err)
-				_, err = w.Write([]byte((<-c.send).String()))
+				_, err = w.Write([]byte(queued.Payload))
 				log.Println("Websocket error:", err)
 			}
 
@@ -287,6 +492,12 @@ func (c *Client) writePump() {
 				return
 			}
 
+			// Members of a deleted club are disconnected once they have been told
+			if jsonMessage["action"] == "club_deleted" {
+				_ = c.conn.Close()
+				return
+			}
+
 		case <-ticker.C:
 			err := c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			log.Println("Websocket error:", err)
@@ -299,8 +510,16 @@ func (c *Client) writePump() {
 	}
 }
 
+// protocol returns the version of the websocket protocol negotiated with the peer
+func protocol(conn *websocket.Conn) string {
+	if conn.Subprotocol() == "" {
+		return ProtocolV1
+	}
+	return conn.Subprotocol()
+}
+
 // ServeWs handles websocket requests from the peer.
-func ServeWs(conn *websocket.Conn, userID string, clubID string) {
+func ServeWs(conn *websocket.Conn, userID string, tokenVersion int, clubID string, locale string) {
 
 	ctx := context.Background()
 	rdb, err := redisclient.GetRedisClient()
@@ -310,7 +529,7 @@ func ServeWs(conn *websocket.Conn, userID string, clubID string) {
 	}
 	pubsub := rdb.Subscribe(ctx, clubID)
 	c := pubsub.Channel()
-	client := &Client{UserID: userID, ClubID: clubID, PubSub: pubsub, conn: conn, send: c}
+	client := &Client{UserID: userID, TokenVersion: tokenVersion, ClubID: clubID, Locale: locale, Protocol: protocol(conn), PubSub: pubsub, conn: conn, send: c}
 	client.conn.SetReadLimit(maxMessageSize)
 	err = client.conn.SetReadDeadline(time.Now().Add(pongWait))
 	client.conn.SetPongHandler(func(string) error {
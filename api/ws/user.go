@@ -0,0 +1,124 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/redisclient"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// PublishToUser publishes a message to the personal websockets of a user
+func PublishToUser(userID string, message fiber.Map) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	byteMessage, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return rdb.Publish(context.Background(), models.UserChannel(userID), byteMessage).Err()
+}
+
+// PublishRead tells the other user of a conversation that userID has read it
+func PublishRead(conversation *models.Conversation, userID string, timeRead time.Time) error {
+	otherID := conversation.UserAID.String()
+	if otherID == userID {
+		otherID = conversation.UserBID.String()
+	}
+	return PublishToUser(otherID, fiber.Map{
+		"user_id": userID,
+		"action":  "read",
+		"data": fiber.Map{
+			"conversation_id": conversation.ID,
+			"time_read":       timeRead,
+		},
+	})
+}
+
+// handleUserAction handles a message received on a personal websocket.
+//
+// Direct messages reach both users through the AfterInsert hook of
+// models.DirectMessage, so nothing is published from here for them.
+func (c *Client) handleUserAction(rdb *redis.Client, jsonMessage map[string]interface{}) {
+	var err error
+	switch jsonMessage["action"] {
+	case "direct_message":
+		var dataJSON []byte
+		dataJSON, err = json.Marshal(jsonMessage["data"])
+		log.Println("Websocket error:", err)
+		var message schemas.DirectMessageCreate
+		err = json.Unmarshal(dataJSON, &message)
+		if err != nil {
+			err = c.conn.WriteJSON(errors.Frame(errors.ErrMalformedJSON, c.Locale))
+			log.Println("Websocket error:", err)
+			return
+		}
+		message.SenderID = c.UserID
+		if !c.validate(&message) {
+			return
+		}
+		_, err = crud.CreateDirectMessage(&message)
+		if err != nil {
+			err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
+			log.Println("Websocket error:", err)
+		}
+	case "read":
+		conversationID, ok := jsonMessage["data"].(string)
+		var conversation *models.Conversation
+		if ok {
+			conversation, err = crud.GetConversation(conversationID, c.UserID)
+		}
+		if !ok || err != nil {
+			err = c.conn.WriteJSON(errors.Frame(errors.ErrConversationNotFound, c.Locale))
+			log.Println("Websocket error:", err)
+			return
+		}
+		var timeRead time.Time
+		timeRead, err = crud.MarkConversationRead(conversationID, c.UserID)
+		if err == nil {
+			err = PublishRead(conversation, c.UserID, timeRead)
+		}
+		if err != nil {
+			err = c.conn.WriteJSON(errors.Frame(err, c.Locale))
+			log.Println("Websocket error:", err)
+		}
+	default:
+		err = c.conn.WriteJSON(errors.Frame(errors.ErrUnknownAction, c.Locale))
+		log.Println("Websocket error:", err)
+	}
+}
+
+// ServeUserWs handles personal websocket requests from the peer, which carry
+// direct messages and notifications
+func ServeUserWs(conn *websocket.Conn, userID string, tokenVersion int, locale string) {
+	ctx := context.Background()
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	pubsub := rdb.Subscribe(ctx, models.UserChannel(userID))
+	c := pubsub.Channel()
+	client := &Client{UserID: userID, TokenVersion: tokenVersion, Locale: locale, Protocol: protocol(conn), PubSub: pubsub, conn: conn, send: c}
+	client.conn.SetReadLimit(maxMessageSize)
+	err = client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	log.Println("Websockets error:", err)
+	client.conn.SetPongHandler(func(string) error {
+		err = client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		log.Println("Websockets error:", err)
+		return nil
+	})
+	go client.writePump()
+	client.readPump(rdb)
+}
@@ -0,0 +1,251 @@
+package asyncapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/api/ws"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/openapi"
+)
+
+// schemaFormat tells readers of the document that payloads are OpenAPI schemas
+const schemaFormat = "application/vnd.oai.openapi+json;version=3.0.0"
+
+// Document is an AsyncAPI 2.0 document
+type Document struct {
+	AsyncAPI           string                  `json:"asyncapi"`
+	Info               openapi.Info            `json:"info"`
+	DefaultContentType string                  `json:"defaultContentType"`
+	Channels           map[string]*ChannelItem `json:"channels"`
+	Components         Components              `json:"components"`
+}
+
+// ChannelItem describes a websocket endpoint and the frames sent over it
+type ChannelItem struct {
+	Description string                `json:"description"`
+	Parameters  map[string]*Parameter `json:"parameters,omitempty"`
+	// Publish lists the frames clients send
+	Publish *Operation `json:"publish"`
+	// Subscribe lists the frames clients receive
+	Subscribe *Operation          `json:"subscribe"`
+	Bindings  map[string]*Binding `json:"bindings"`
+}
+
+// Parameter is a parameter of the path of a channel
+type Parameter struct {
+	Description string          `json:"description"`
+	Schema      *openapi.Schema `json:"schema"`
+}
+
+// Operation lists the messages sent one way over a channel
+type Operation struct {
+	OperationID string `json:"operationId"`
+	Summary     string `json:"summary"`
+	Message     OneOf  `json:"message"`
+}
+
+// OneOf refers to the messages an operation may send
+type OneOf struct {
+	OneOf []*Ref `json:"oneOf"`
+}
+
+// Ref refers to a component
+type Ref struct {
+	Ref string `json:"$ref"`
+}
+
+// Binding describes how the websocket of a channel is opened
+type Binding struct {
+	Method         string          `json:"method"`
+	Query          *openapi.Schema `json:"query"`
+	Headers        *openapi.Schema `json:"headers"`
+	BindingVersion string          `json:"bindingVersion"`
+}
+
+// Components holds the messages and the schemas their payloads refer to
+type Components struct {
+	Messages map[string]*MessageObject  `json:"messages"`
+	Schemas  map[string]*openapi.Schema `json:"schemas"`
+}
+
+// MessageObject is a message of AsyncAPI
+type MessageObject struct {
+	Name         string          `json:"name"`
+	Summary      string          `json:"summary"`
+	SchemaFormat string          `json:"schemaFormat"`
+	Payload      *openapi.Schema `json:"payload"`
+}
+
+const description = "Websocket protocol of Keats. Every frame is a JSON object " +
+	"whose action field names what it is. Websockets are opened with the JWT of " +
+	"the user in the token query parameter, and the version of the protocol is " +
+	"negotiated in the Sec-WebSocket-Protocol header of the upgrade request. " +
+	"Clients offering none speak " + ws.ProtocolV1 + ", in which the server may " +
+	"batch several objects into one frame, separated by newlines. In " +
+	ws.ProtocolV2 + " every frame holds a single object."
+
+var (
+	document     *Document
+	documentJSON []byte
+	documentOnce sync.Once
+)
+
+// Spec returns the AsyncAPI document of the websocket protocol, built from
+// Messages and the types of the schemas and models packages
+func Spec() *Document {
+	documentOnce.Do(func() {
+		document = build()
+		var err error
+		if documentJSON, err = json.Marshal(document); err != nil {
+			panic(err)
+		}
+	})
+	return document
+}
+
+// messageID names the component of a message, e.g. inbound.chatmessage
+func messageID(m *Message) string {
+	if m.Inbound {
+		return "inbound." + m.Action
+	}
+	return "outbound." + m.Action
+}
+
+func build() *Document {
+	g := openapi.NewGenerator()
+	doc := &Document{
+		AsyncAPI: "2.0.0",
+		Info: openapi.Info{
+			Title:       "Keats Websockets",
+			Description: description,
+			Version:     "2.0.0",
+		},
+		DefaultContentType: fiber.MIMEApplicationJSON,
+		Channels: map[string]*ChannelItem{
+			ClubChannel: {
+				Description: "Chat, comments and events of a club, open to its members",
+				Parameters: map[string]*Parameter{
+					"id": {Description: "Id of the club", Schema: &openapi.Schema{Type: "string", Format: "uuid"}},
+				},
+				Publish:   &Operation{OperationID: "sendClubMessage", Summary: "Frames sent by members"},
+				Subscribe: &Operation{OperationID: "receiveClubMessage", Summary: "Frames sent to members"},
+			},
+			UserChannel: {
+				Description: "Direct messages and notifications of the signed in user",
+				Publish:     &Operation{OperationID: "sendUserMessage", Summary: "Frames sent by the user"},
+				Subscribe:   &Operation{OperationID: "receiveUserMessage", Summary: "Frames sent to the user"},
+			},
+		},
+		Components: Components{Messages: map[string]*MessageObject{}},
+	}
+	token := &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"token": {Type: "string"}},
+		Required:   []string{"token"},
+	}
+	protocol := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"Sec-WebSocket-Protocol": {Type: "string", Enum: ws.Protocols},
+		},
+	}
+	for _, channel := range doc.Channels {
+		channel.Bindings = map[string]*Binding{
+			"ws": {Method: fiber.MethodGet, Query: token, Headers: protocol, BindingVersion: "0.1.0"},
+		}
+	}
+	for _, m := range Messages {
+		id := messageID(m)
+		doc.Components.Messages[id] = &MessageObject{
+			Name:         m.Action,
+			Summary:      m.Summary,
+			SchemaFormat: schemaFormat,
+			Payload:      payload(g, m),
+		}
+		for _, name := range m.Channels {
+			operation := doc.Channels[name].Subscribe
+			if m.Inbound {
+				operation = doc.Channels[name].Publish
+			}
+			operation.Message.OneOf = append(operation.Message.OneOf, &Ref{Ref: "#/components/messages/" + id})
+		}
+	}
+	doc.Components.Schemas = g.Components()
+	return doc
+}
+
+// payload describes the frame of a message, whose action is fixed
+func payload(g *openapi.Generator, m *Message) *openapi.Schema {
+	fields := openapi.Object{"action": ""}
+	for name, value := range m.Fields {
+		fields[name] = value
+	}
+	s := g.SchemaOf(fields, m.Inbound)
+	s.Properties["action"].Enum = []string{m.Action}
+	if m.Action == "error" {
+		for _, err := range errors.All() {
+			s.Properties["code"].Enum = append(s.Properties["code"].Enum, err.Code)
+		}
+	}
+	return s
+}
+
+// Serve responds with the AsyncAPI document of the websocket protocol
+func Serve(c *fiber.Ctx) error {
+	Spec()
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(documentJSON)
+}
+
+// CheckFrame returns where a frame sent over channel differs from the
+// document, inbound telling whether it was sent by a client. Frames batching
+// several messages, as the first version of the protocol does, are checked
+// message by message.
+func (d *Document) CheckFrame(channel string, inbound bool, frame []byte) []string {
+	item, ok := d.Channels[channel]
+	if !ok {
+		return []string{"unknown channel " + channel}
+	}
+	operation := item.Subscribe
+	if inbound {
+		operation = item.Publish
+	}
+	var violations []string
+	for _, line := range bytes.Split(frame, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var value map[string]interface{}
+		if err := json.Unmarshal(line, &value); err != nil {
+			violations = append(violations, "frame is not a JSON object: "+err.Error())
+			continue
+		}
+		message := d.findMessage(operation, value["action"])
+		if message == nil {
+			violations = append(violations, "unknown action "+toString(value["action"]))
+			continue
+		}
+		violations = append(violations, openapi.Check(message.Payload, d.Components.Schemas, value, message.Name)...)
+	}
+	return violations
+}
+
+// findMessage finds the message of an operation sent with action
+func (d *Document) findMessage(operation *Operation, action interface{}) *MessageObject {
+	for _, ref := range operation.Message.OneOf {
+		message := d.Components.Messages[ref.Ref[len("#/components/messages/"):]]
+		if message != nil && message.Name == action {
+			return message
+		}
+	}
+	return nil
+}
+
+func toString(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
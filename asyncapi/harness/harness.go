@@ -0,0 +1,226 @@
+// Package harness exercises the websockets of a running server and checks
+// every frame sent and received against the AsyncAPI document the server
+// serves at /api/asyncapi.json. Chat messages and comments sent by the
+// harness are kept in the club.
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/api/ws"
+	"github.com/Krishap-s/keats-backend/asyncapi"
+)
+
+// replyWait is how long a reply to a frame is waited for
+const replyWait = 5 * time.Second
+
+// Harness exercises the websockets of Server as the user signed in with
+// Token, speaking the version of the protocol given by Protocol, none
+// offering no subprotocol on upgrade as clients predating versions do
+type Harness struct {
+	Server   string
+	Token    string
+	Protocol string
+	// Logf reports every reply received and every failure, log.Printf when
+	// nil
+	Logf     func(format string, args ...interface{})
+	spec     *asyncapi.Document
+	failures int
+}
+
+// Run exercises the club channel of clubID, which the user must be a member
+// of, and the personal channel, and returns the number of frames that drifted
+// from the document or replies that never arrived
+func (h *Harness) Run(clubID string) (int, error) {
+	if h.Logf == nil {
+		h.Logf = log.Printf
+	}
+	if err := h.fetchSpec(); err != nil {
+		return 0, err
+	}
+	h.exerciseClub(clubID)
+	h.exerciseUser()
+	return h.failures, nil
+}
+
+func (h *Harness) failf(format string, args ...interface{}) {
+	h.failures++
+	h.Logf("FAIL "+format, args...)
+}
+
+// session is a websocket opened on a channel of the protocol
+type session struct {
+	h       *Harness
+	conn    *websocket.Conn
+	channel string
+}
+
+// fetchSpec loads the AsyncAPI document served by the server
+func (h *Harness) fetchSpec() error {
+	resp, err := http.Get(h.Server + "/api/asyncapi.json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("asyncapi.json returned %s", resp.Status)
+	}
+	h.spec = new(asyncapi.Document)
+	return json.NewDecoder(resp.Body).Decode(h.spec)
+}
+
+// open opens the websocket at path, which is sent over channel
+func (h *Harness) open(path string, channel string) (*session, error) {
+	u, err := url.Parse(h.Server + path)
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.RawQuery = url.Values{"token": {h.Token}}.Encode()
+	dialer := *websocket.DefaultDialer
+	want := ""
+	if h.Protocol != "none" {
+		dialer.Subprotocols = []string{h.Protocol}
+		want = h.Protocol
+	}
+	conn, _, err := dialer.Dial(u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if got := conn.Subprotocol(); got != want {
+		h.failf("negotiated protocol %q instead of %q", got, want)
+	}
+	return &session{h: h, conn: conn, channel: channel}, nil
+}
+
+// send checks a frame against the document and sends it
+func (s *session) send(frame map[string]interface{}) {
+	b, err := json.Marshal(frame)
+	if err != nil {
+		s.h.failf("%s: %v", frame["action"], err)
+		return
+	}
+	for _, violation := range s.h.spec.CheckFrame(s.channel, true, b) {
+		s.h.failf("sent %s", violation)
+	}
+	if err = s.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+		s.h.failf("sending %s: %v", frame["action"], err)
+	}
+}
+
+// expect reads frames until a message with action arrives and returns it.
+// Every frame read on the way is checked against the document.
+func (s *session) expect(action string) map[string]interface{} {
+	deadline := time.Now().Add(replyWait)
+	for {
+		if err := s.conn.SetReadDeadline(deadline); err != nil {
+			s.h.failf("waiting for %s: %v", action, err)
+			return nil
+		}
+		_, frame, err := s.conn.ReadMessage()
+		if err != nil {
+			s.h.failf("waiting for %s: %v", action, err)
+			return nil
+		}
+		for _, violation := range s.h.spec.CheckFrame(s.channel, false, frame) {
+			s.h.failf("received %s", violation)
+		}
+		if s.h.Protocol == ws.ProtocolV2 && strings.Count(strings.TrimSpace(string(frame)), "\n") != 0 {
+			s.h.failf("received a frame batching several messages in %s", ws.ProtocolV2)
+		}
+		for _, line := range strings.Split(string(frame), "\n") {
+			var message map[string]interface{}
+			if json.Unmarshal([]byte(line), &message) == nil && message["action"] == action {
+				s.h.Logf("ok   %s", action)
+				return message
+			}
+		}
+	}
+}
+
+// expectError expects an error frame reporting code
+func (s *session) expectError(code string) {
+	message := s.expect("error")
+	if message != nil && message["code"] != code {
+		s.h.failf("expected error %s, got %v", code, message["code"])
+	}
+}
+
+// id returns the id of the object sent as data of a message
+func id(message map[string]interface{}) string {
+	if message == nil {
+		return ""
+	}
+	data, _ := message["data"].(map[string]interface{})
+	value, _ := data["id"].(string)
+	return value
+}
+
+// exerciseClub sends every action of the club channel and checks the replies
+func (h *Harness) exerciseClub(clubID string) {
+	s, err := h.open("/api/ws/"+clubID, asyncapi.ClubChannel)
+	if err != nil {
+		h.failf("opening club websocket: %v", err)
+		return
+	}
+	defer s.conn.Close()
+
+	text := "wsharness " + time.Now().Format(time.RFC3339)
+	s.send(map[string]interface{}{"action": "chatmessage", "data": text})
+	chatMessageID := id(s.expect("chatmessage"))
+	if chatMessageID != "" {
+		s.send(map[string]interface{}{"action": "like_chatmessage", "data": chatMessageID})
+		s.expect("like_chatmessage")
+	}
+
+	s.send(map[string]interface{}{"action": "comment", "data": map[string]interface{}{
+		"parent_id": clubID,
+		"page_no":   1,
+		"message":   text,
+	}})
+	commentID := id(s.expect("comment"))
+	if commentID != "" {
+		s.send(map[string]interface{}{"action": "like_comment", "data": commentID})
+		s.expect("like_comment")
+	}
+
+	s.send(map[string]interface{}{"action": "chatmessage", "data": strings.Repeat("x", 151)})
+	s.expectError("validation_failed")
+	s.send(map[string]interface{}{"action": "like_comment", "data": uuid.New().String()})
+	s.expectError("comment_not_found")
+	s.sendUnknown()
+}
+
+// exerciseUser sends the actions of the personal channel that need no
+// conversation and checks the replies
+func (h *Harness) exerciseUser() {
+	s, err := h.open("/api/ws/me", asyncapi.UserChannel)
+	if err != nil {
+		h.failf("opening personal websocket: %v", err)
+		return
+	}
+	defer s.conn.Close()
+
+	s.send(map[string]interface{}{"action": "read", "data": uuid.New().String()})
+	s.expectError("conversation_not_found")
+	s.sendUnknown()
+}
+
+// sendUnknown sends an action outside of the protocol, which must be refused
+func (s *session) sendUnknown() {
+	b, _ := json.Marshal(map[string]interface{}{"action": "wsharness_unknown"})
+	if err := s.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+		s.h.failf("sending unknown action: %v", err)
+		return
+	}
+	s.expectError("unknown_action")
+}
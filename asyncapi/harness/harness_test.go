@@ -0,0 +1,52 @@
+package harness_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Krishap-s/keats-backend/api/server"
+	"github.com/Krishap-s/keats-backend/api/server/servertest"
+	"github.com/Krishap-s/keats-backend/api/ws"
+	"github.com/Krishap-s/keats-backend/asyncapi/harness"
+	"github.com/Krishap-s/keats-backend/models"
+)
+
+// TestProtocol exercises the websockets of the server in every version of the
+// protocol and checks the frames against the AsyncAPI document
+func TestProtocol(t *testing.T) {
+	servertest.Setup(t)
+	app := server.New()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = app.Listener(ln)
+	}()
+	t.Cleanup(func() {
+		if err := app.Shutdown(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	for _, protocol := range []string{ws.ProtocolV2, ws.ProtocolV1, "none"} {
+		protocol := protocol
+		t.Run(protocol, func(t *testing.T) {
+			user := servertest.User(t, models.RoleMember)
+			club := servertest.Club(t, user)
+			h := &harness.Harness{
+				Server:   "http://" + ln.Addr().String(),
+				Token:    servertest.Token(t, user),
+				Protocol: protocol,
+				Logf:     t.Logf,
+			}
+			failures, err := h.Run(club.ID.String())
+			if err != nil {
+				t.Fatal("Fetching AsyncAPI document:", err)
+			}
+			if failures != 0 {
+				t.Errorf("%d failures", failures)
+			}
+		})
+	}
+}
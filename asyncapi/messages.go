@@ -0,0 +1,79 @@
+package asyncapi
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/openapi"
+	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/Krishap-s/keats-backend/validation"
+)
+
+// Channels of the websocket protocol
+const (
+	// ClubChannel carries the chat, comments and events of a club to its members
+	ClubChannel = "/api/ws/{id}"
+	// UserChannel carries direct messages and notifications of the signed in user
+	UserChannel = "/api/ws/me"
+)
+
+// Message describes a frame of the websocket protocol, a JSON object whose
+// action field names what it is
+type Message struct {
+	Action  string
+	Summary string
+	// Channels the message is sent on
+	Channels []string
+	// Inbound messages are sent by clients, the others by the server
+	Inbound bool
+	// Fields are the fields of the frame besides action, as for openapi.Object
+	Fields openapi.Object
+}
+
+var (
+	bothChannels = []string{ClubChannel, UserChannel}
+	clubChannel  = []string{ClubChannel}
+	userChannel  = []string{UserChannel}
+)
+
+// Messages lists every frame of the websocket protocol. Frames published by
+// the hooks of models reach every client subscribed to the channel of the
+// club or user they concern.
+var Messages = []*Message{
+	// Sent by clients
+	{Action: "chatmessage", Summary: "Send a chat message to the club", Channels: clubChannel, Inbound: true, Fields: openapi.Object{"data": ""}},
+	{Action: "like_chatmessage", Summary: "Like a chat message of the club by its id", Channels: clubChannel, Inbound: true, Fields: openapi.Object{"data": uuid.UUID{}}},
+	{Action: "comment", Summary: "Comment on a page of the book of the club", Channels: clubChannel, Inbound: true, Fields: openapi.Object{"data": openapi.Partial(schemas.CommentCreate{}, "id", "club_id", "user_id", "likes")}},
+	{Action: "like_comment", Summary: "Like a comment of the club by its id", Channels: clubChannel, Inbound: true, Fields: openapi.Object{"data": uuid.UUID{}}},
+	{Action: "report", Summary: "Report a chat message or comment of the club to moderators", Channels: clubChannel, Inbound: true, Fields: openapi.Object{"data": schemas.ReportCreate{}}},
+	{Action: "direct_message", Summary: "Send a direct message in a conversation", Channels: userChannel, Inbound: true, Fields: openapi.Object{"data": openapi.Partial(schemas.DirectMessageCreate{}, "sender_id")}},
+	{Action: "read", Summary: "Mark a conversation as read by its id", Channels: userChannel, Inbound: true, Fields: openapi.Object{"data": uuid.UUID{}}},
+
+	// Sent by the server
+	{Action: "chatmessage", Summary: "A member sent a chat message", Channels: clubChannel, Fields: openapi.Object{"user_id": "", "data": models.ChatMessage{}}},
+	{Action: "like_chatmessage", Summary: "A member liked a chat message", Channels: clubChannel, Fields: openapi.Object{"user_id": "", "chatmessage_id": uuid.UUID{}}},
+	{Action: "comment", Summary: "A member commented", Channels: clubChannel, Fields: openapi.Object{"user_id": "", "data": models.Comment{}}},
+	{Action: "like_comment", Summary: "A member liked a comment", Channels: clubChannel, Fields: openapi.Object{"user_id": "", "comment_id": uuid.UUID{}}},
+	{Action: "mention", Summary: "The client was mentioned in a chat message or comment, sent to the mentioned user only", Channels: clubChannel, Fields: openapi.Object{
+		"user_id": "",
+		"to":      uuid.UUID{},
+		"data": struct {
+			Type        string              `json:"type"`
+			ChatMessage *models.ChatMessage `json:"chatmessage,omitempty"`
+			Comment     *models.Comment     `json:"comment,omitempty"`
+		}{},
+	}},
+	{Action: "report", Summary: "The report sent by the client was filed, sent to the reporter only", Channels: clubChannel, Fields: openapi.Object{"data": models.Report{}}},
+	{Action: "chatmessage_hidden", Summary: "A moderator hid or restored a chat message", Channels: clubChannel, Fields: openapi.Object{"chatmessage_id": uuid.UUID{}, "hidden": false}},
+	{Action: "comment_hidden", Summary: "A moderator hid or restored a comment", Channels: clubChannel, Fields: openapi.Object{"comment_id": uuid.UUID{}, "hidden": false}},
+	{Action: "club_update", Summary: "The club was updated", Channels: clubChannel, Fields: openapi.Object{"data": models.Club{}}},
+	{Action: "club_deleted", Summary: "The club was deleted, the connection is closed after it", Channels: clubChannel, Fields: openapi.Object{"data": uuid.UUID{}}},
+	{Action: "user_join", Summary: "A user joined the club, data is their id", Channels: clubChannel, Fields: openapi.Object{"data": uuid.UUID{}}},
+	{Action: "user_leave", Summary: "A user left or was kicked out of the club, data is their id", Channels: clubChannel, Fields: openapi.Object{"data": uuid.UUID{}}},
+	{Action: "direct_message", Summary: "A direct message was sent to or by the user", Channels: userChannel, Fields: openapi.Object{"user_id": "", "data": models.DirectMessage{}}},
+	{Action: "read", Summary: "The other user of a conversation read it", Channels: userChannel, Fields: openapi.Object{"user_id": "", "data": openapi.Object{"conversation_id": uuid.UUID{}, "time_read": time.Time{}}}},
+	{Action: "notification", Summary: "A notification was delivered to the user", Channels: userChannel, Fields: openapi.Object{"data": models.Notification{}}},
+	{Action: "error", Summary: "A frame sent by the client failed, message is in the locale of the user", Channels: bothChannels, Fields: openapi.Object{"code": "", "message": "", "details": validation.Errors(nil)}},
+}
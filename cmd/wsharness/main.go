@@ -0,0 +1,51 @@
+// Command wsharness exercises the websockets of a running server and checks
+// every frame sent and received against the AsyncAPI document the server
+// serves at /api/asyncapi.json, using package harness.
+//
+//	go run ./cmd/wsharness -server http://localhost:3000 -token <jwt> -club <club id>
+//
+// The websockets speak the version of the protocol given by -protocol, none
+// offering no subprotocol on upgrade as clients predating versions do. The
+// user of the token must be a member of the club. Chat messages and comments
+// sent by the harness are kept in the club. It exits with status 1 when a
+// frame drifts from the document or an expected reply never arrives.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Krishap-s/keats-backend/api/ws"
+	"github.com/Krishap-s/keats-backend/asyncapi/harness"
+)
+
+func main() {
+	server := flag.String("server", "http://localhost:3000", "address of the server")
+	token := flag.String("token", "", "JWT of a user")
+	clubID := flag.String("club", "", "id of a club the user is a member of")
+	protocol := flag.String("protocol", ws.ProtocolV2, "version of the protocol to speak, "+
+		ws.ProtocolV2+", "+ws.ProtocolV1+" or none")
+	flag.Parse()
+	if *token == "" || *clubID == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *protocol != ws.ProtocolV2 && *protocol != ws.ProtocolV1 && *protocol != "none" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	h := &harness.Harness{Server: strings.TrimRight(*server, "/"), Token: *token, Protocol: *protocol}
+	failures, err := h.Run(*clubID)
+	if err != nil {
+		log.Fatalln("Fetching AsyncAPI document:", err)
+	}
+	if failures != 0 {
+		log.Printf("%d failures", failures)
+		os.Exit(1)
+	}
+	log.Println("All frames match the AsyncAPI document")
+}
@@ -7,5 +7,7 @@ import (
 func CORSConfig() cors.Config {
 	return cors.Config{
 		AllowOrigins: "*",
+		// Tells browsers of deprecated versions of the API where to move
+		ExposeHeaders: "Deprecation, Sunset, Link",
 	}
 }
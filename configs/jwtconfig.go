@@ -14,6 +14,8 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
 )
 
 func GetSecret() string {
@@ -24,6 +26,13 @@ func GetSecret() string {
 	return secret
 }
 
+// TokenIsCurrent reports whether a token was issued since the sessions of its
+// user were last invalidated
+func TokenIsCurrent(claims jwt.MapClaims, user *models.User) bool {
+	version, _ := claims["ver"].(float64)
+	return int(version) == user.TokenVersion
+}
+
 func JWTConfig() jwtware.Config {
 	return jwtware.Config{
 		Filter: func(c *fiber.Ctx) bool {
@@ -35,10 +44,10 @@ func JWTConfig() jwtware.Config {
 		},
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			if err.Error() == "Missing or malformed JWT" {
-				return fmt.Errorf("malformed jwt")
+				return errors.ErrMalformedJWT
 			}
 
-			return fmt.Errorf("invalid jwt")
+			return errors.ErrInvalidJWT
 		},
 		SuccessHandler: func(c *fiber.Ctx) error {
 			token := c.Locals("user").(*jwt.Token)
@@ -47,11 +56,18 @@ func JWTConfig() jwtware.Config {
 			user, err := crud.GetUser(id)
 			if err != nil {
 				if err == pg.ErrNoRows {
-					return fmt.Errorf("invalid jwt")
+					return errors.ErrInvalidJWT
 				}
 				return err
 			}
+			if !TokenIsCurrent(claims, user) {
+				return errors.ErrInvalidJWT
+			}
+			if user.IsSuspended() {
+				return errors.ErrSuspended
+			}
 			c.Locals("user", user)
+			c.Locals("locale", user.Locale)
 			return c.Next()
 		},
 		SigningKey:    []byte(GetSecret()),
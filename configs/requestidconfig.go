@@ -0,0 +1,7 @@
+package configs
+
+import "github.com/gofiber/fiber/v2/middleware/requestid"
+
+func RequestIDConfig() requestid.Config {
+	return requestid.Config{}
+}
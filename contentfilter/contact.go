@@ -0,0 +1,94 @@
+package contentfilter
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/errors"
+)
+
+var (
+	linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|me|ly|co|app|xyz|info|link|gg)\b\S*`)
+	// phonePattern finds runs of digits that may be phone numbers, which
+	// isPhoneNumber then tells apart from ISBNs, dates, years and page ranges
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d\s().-]{6,}\d`)
+)
+
+// phoneMask replaces phone numbers in messages of standard clubs
+const phoneMask = "[phone number removed]"
+
+// ContactFilter keeps links and phone numbers out of clubs, standard clubs
+// mask phone numbers while strict clubs reject both. Direct messages are only
+// checked when CONTENT_FILTER_MASK_DIRECT_MESSAGES is set.
+type ContactFilter struct{}
+
+// Check masks or rejects links and phone numbers
+func (f *ContactFilter) Check(ctx context.Context, message *Message) error {
+	if message.ConversationID != "" && !viper.GetBool("CONTENT_FILTER_MASK_DIRECT_MESSAGES") {
+		return nil
+	}
+	switch message.Strictness {
+	case Strict:
+		if linkPattern.MatchString(message.Text) || containsPhoneNumber(message.Text) {
+			return errors.ErrMessageRejected
+		}
+	case Standard:
+		message.Text = maskPhoneNumbers(message.Text)
+	}
+	return nil
+}
+
+func containsPhoneNumber(text string) bool {
+	for _, candidate := range phonePattern.FindAllString(text, -1) {
+		if isPhoneNumber(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func maskPhoneNumbers(text string) string {
+	return phonePattern.ReplaceAllStringFunc(text, func(candidate string) string {
+		if isPhoneNumber(candidate) {
+			return phoneMask
+		}
+		return candidate
+	})
+}
+
+// isPhoneNumber tells whether candidate is grouped as phone numbers are: 8 to
+// 15 digits after a leading +, otherwise 10 or 11, in groups of at least two
+// digits past the first, split by single spaces, dots or hyphens or with the
+// area code in parentheses. Two groups joined by a hyphen are a range.
+func isPhoneNumber(candidate string) bool {
+	notDigit := func(r rune) bool {
+		return !unicode.IsDigit(r)
+	}
+	groups := strings.FieldsFunc(candidate, notDigit)
+	digits := 0
+	for i, group := range groups {
+		digits += len(group)
+		if i > 0 && len(group) < 2 {
+			return false
+		}
+	}
+	if strings.HasPrefix(candidate, "+") {
+		if digits < 8 || digits > 15 {
+			return false
+		}
+	} else if digits < 10 || digits > 11 {
+		return false
+	}
+	unwrapped := strings.NewReplacer("(", "", ")", "").Replace(strings.TrimPrefix(candidate, "+"))
+	separators := strings.FieldsFunc(unwrapped, unicode.IsDigit)
+	for _, separator := range separators {
+		if len(separator) > 1 {
+			return false
+		}
+	}
+	return !(len(groups) == 2 && len(separators) == 1 && separators[0] == "-")
+}
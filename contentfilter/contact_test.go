@@ -0,0 +1,100 @@
+package contentfilter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/contentfilter"
+	"github.com/Krishap-s/keats-backend/errors"
+)
+
+func TestContactFilterMasksPhoneNumbers(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"ISBN 978-0-14-118776-1", "ISBN 978-0-14-118776-1"},
+		{"ISBN 0-14-118776-1", "ISBN 0-14-118776-1"},
+		{"ISBN 9780141187761", "ISBN 9780141187761"},
+		{"we meet on 2021-10-19", "we meet on 2021-10-19"},
+		{"we meet on 19.10.2021", "we meet on 19.10.2021"},
+		{"read pages 120 - 180", "read pages 120 - 180"},
+		{"read pages 12000-18000", "read pages 12000-18000"},
+		{"1984 (1949)", "1984 (1949)"},
+		{"chapters 1 2 3 4 5 6 7 8 9 10", "chapters 1 2 3 4 5 6 7 8 9 10"},
+		{"call me on 555-123-4567", "call me on [phone number removed]"},
+		{"call me on (555) 123-4567", "call me on [phone number removed]"},
+		{"call me on 5551234567", "call me on [phone number removed]"},
+		{"call me on 1-800-555-1234", "call me on [phone number removed]"},
+		{"call me on +1 555 123 4567", "call me on [phone number removed]"},
+		{"call me on +91 98765 43210", "call me on [phone number removed]"},
+		{"call me on +44 20.7946.0958", "call me on [phone number removed]"},
+		{"call me on 98765 43210", "call me on [phone number removed]"},
+	}
+	filter := &contentfilter.ContactFilter{}
+	for _, c := range cases {
+		message := &contentfilter.Message{
+			ClubID:     "club",
+			Strictness: contentfilter.Standard,
+			Text:       c.text,
+		}
+		if err := filter.Check(context.Background(), message); err != nil {
+			t.Errorf("%q: %v", c.text, err)
+			continue
+		}
+		if message.Text != c.want {
+			t.Errorf("%q was masked to %q, want %q", c.text, message.Text, c.want)
+		}
+	}
+}
+
+func TestContactFilterRejectsInStrictClubs(t *testing.T) {
+	cases := []struct {
+		text     string
+		rejected bool
+	}{
+		{"ISBN 978-0-14-118776-1", false},
+		{"we meet on 2021-10-19", false},
+		{"read pages 120 - 180", false},
+		{"1984 (1949)", false},
+		{"call me on +1 555 123 4567", true},
+		{"read it at www.example.com", true},
+	}
+	filter := &contentfilter.ContactFilter{}
+	for _, c := range cases {
+		message := &contentfilter.Message{
+			ClubID:     "club",
+			Strictness: contentfilter.Strict,
+			Text:       c.text,
+		}
+		err := filter.Check(context.Background(), message)
+		if c.rejected && err != errors.ErrMessageRejected {
+			t.Errorf("%q was not rejected: %v", c.text, err)
+		}
+		if !c.rejected && err != nil {
+			t.Errorf("%q was rejected: %v", c.text, err)
+		}
+	}
+}
+
+func TestContactFilterSkipsDirectMessages(t *testing.T) {
+	text := "call me on +1 555 123 4567"
+	filter := &contentfilter.ContactFilter{}
+	for _, mask := range []bool{false, true} {
+		viper.Set("CONTENT_FILTER_MASK_DIRECT_MESSAGES", mask)
+		message := &contentfilter.Message{
+			ConversationID: "conversation",
+			Strictness:     contentfilter.Standard,
+			Text:           text,
+		}
+		if err := filter.Check(context.Background(), message); err != nil {
+			t.Fatal(err)
+		}
+		if masked := message.Text != text; masked != mask {
+			t.Errorf("with CONTENT_FILTER_MASK_DIRECT_MESSAGES %v the direct message was masked to %q", mask, message.Text)
+		}
+	}
+	viper.Set("CONTENT_FILTER_MASK_DIRECT_MESSAGES", false)
+}
@@ -0,0 +1,87 @@
+package contentfilter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Krishap-s/keats-backend/errors"
+)
+
+// Strictness levels hosts can pick for their clubs
+const (
+	Relaxed  = "relaxed"
+	Standard = "standard"
+	Strict   = "strict"
+)
+
+// Strictnesses are the strictness levels a club may be set to
+var Strictnesses = map[string]bool{
+	Relaxed:  true,
+	Standard: true,
+	Strict:   true,
+}
+
+// Message represents a chat message, comment or direct message about to be persisted
+type Message struct {
+	ClubID string
+	// ConversationID is set instead of ClubID for direct messages
+	ConversationID string
+	UserID         string
+	Language       string
+	// Strictness of the club the message is sent to
+	Strictness string
+	// Text may be rewritten by filters that mask what they find
+	Text string
+}
+
+// Filter checks a message before it is persisted, masking parts of its text
+// or rejecting it with an error
+type Filter interface {
+	Check(ctx context.Context, message *Message) error
+}
+
+// scope names where a message is sent, flood and duplicate limits are counted
+// per user in each club or conversation
+func (m *Message) scope() string {
+	if m.ConversationID != "" {
+		return "conversation_" + m.ConversationID
+	}
+	return m.ClubID
+}
+
+// Pipeline runs filters in order, stopping at the first rejection
+type Pipeline []Filter
+
+// Check runs every filter of the pipeline on the message
+func (p Pipeline) Check(ctx context.Context, message *Message) error {
+	if !Strictnesses[message.Strictness] {
+		message.Strictness = Standard
+	}
+	message.Language = LanguageCode(message.Language)
+	for _, filter := range p {
+		if err := filter.Check(ctx, message); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(message.Text) == "" {
+		return errors.ErrMessageRejected
+	}
+	return nil
+}
+
+var pipeline Pipeline = nil
+
+// GetPipeline returns a singleton reference to the content filter pipeline
+// run on chat messages, comments and direct messages
+func GetPipeline() Pipeline {
+	if pipeline != nil {
+		return pipeline
+	}
+	pipeline = Pipeline{
+		&FloodFilter{},
+		&DuplicateFilter{},
+		NewWordListFilter(),
+		&ContactFilter{},
+	}
+	return pipeline
+}
@@ -0,0 +1,74 @@
+package contentfilter
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/redisclient"
+)
+
+// floodWindow is the span over which messages of a user in a club are counted
+const floodWindow = 10 * time.Second
+
+// floodLimits are the most messages a user may send to a club in floodWindow
+var floodLimits = map[string]int64{
+	Relaxed:  15,
+	Standard: 8,
+	Strict:   4,
+}
+
+// duplicateWindows are how long the same text may not be sent twice to a club by a user
+var duplicateWindows = map[string]time.Duration{
+	Relaxed:  10 * time.Second,
+	Standard: time.Minute,
+	Strict:   10 * time.Minute,
+}
+
+// FloodFilter rejects messages from users sending too many of them in a short time
+type FloodFilter struct{}
+
+// Check counts the message against the flood limit of the club
+func (f *FloodFilter) Check(ctx context.Context, message *Message) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	key := "flood_" + message.scope() + "_" + message.UserID
+	count, err := rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		rdb.Expire(ctx, key, floodWindow)
+	}
+	if count > floodLimits[message.Strictness] {
+		return errors.ErrFlooding
+	}
+	return nil
+}
+
+// DuplicateFilter rejects a user sending the same text to a club again shortly after
+type DuplicateFilter struct{}
+
+// Check remembers the text and rejects it if it was sent recently
+func (f *DuplicateFilter) Check(ctx context.Context, message *Message) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	normalized := strings.Join(strings.Fields(strings.ToLower(message.Text)), " ")
+	sum := sha1.Sum([]byte(normalized))
+	key := "duplicate_" + message.scope() + "_" + message.UserID + "_" + hex.EncodeToString(sum[:])
+	fresh, err := rdb.SetNX(ctx, key, 1, duplicateWindows[message.Strictness]).Result()
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return errors.ErrDuplicateMessage
+	}
+	return nil
+}
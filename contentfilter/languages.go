@@ -0,0 +1,76 @@
+package contentfilter
+
+import "strings"
+
+// languageCodes maps the names clubs give their language, in English or in
+// the language itself, to the ISO 639-1 code word lists are named after
+var languageCodes = map[string]string{
+	"arabic":     "ar",
+	"العربية":    "ar",
+	"bengali":    "bn",
+	"bangla":     "bn",
+	"বাংলা":      "bn",
+	"chinese":    "zh",
+	"mandarin":   "zh",
+	"中文":         "zh",
+	"dutch":      "nl",
+	"nederlands": "nl",
+	"english":    "en",
+	"french":     "fr",
+	"français":   "fr",
+	"francais":   "fr",
+	"german":     "de",
+	"deutsch":    "de",
+	"gujarati":   "gu",
+	"ગુજરાતી":    "gu",
+	"hindi":      "hi",
+	"हिन्दी":     "hi",
+	"हिंदी":      "hi",
+	"indonesian": "id",
+	"italian":    "it",
+	"italiano":   "it",
+	"japanese":   "ja",
+	"日本語":        "ja",
+	"kannada":    "kn",
+	"ಕನ್ನಡ":      "kn",
+	"korean":     "ko",
+	"한국어":        "ko",
+	"malayalam":  "ml",
+	"മലയാളം":     "ml",
+	"marathi":    "mr",
+	"मराठी":      "mr",
+	"polish":     "pl",
+	"polski":     "pl",
+	"portuguese": "pt",
+	"português":  "pt",
+	"punjabi":    "pa",
+	"ਪੰਜਾਬੀ":     "pa",
+	"russian":    "ru",
+	"русский":    "ru",
+	"spanish":    "es",
+	"español":    "es",
+	"espanol":    "es",
+	"tamil":      "ta",
+	"தமிழ்":      "ta",
+	"telugu":     "te",
+	"తెలుగు":     "te",
+	"turkish":    "tr",
+	"türkçe":     "tr",
+	"urdu":       "ur",
+	"اردو":       "ur",
+}
+
+// LanguageCode returns the ISO 639-1 code of a language given by its name,
+// such as English or Español, or by a code or locale such as en or en-US.
+// Languages it does not know are returned lowercased.
+func LanguageCode(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageCodes[language]; ok {
+		return code
+	}
+	// Locales such as en-US or pt_BR share the word list of their language
+	if i := strings.IndexAny(language, "-_"); i == 2 {
+		return language[:i]
+	}
+	return language
+}
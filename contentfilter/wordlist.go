@@ -0,0 +1,94 @@
+package contentfilter
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/errors"
+)
+
+// wordPattern matches the words of a text in any script
+var wordPattern = regexp.MustCompile(`[\p{L}\p{M}\p{N}]+`)
+
+// WordListFilter masks words found in the word list of the language of the
+// club, and in the list shared by every language, strict clubs reject them instead
+type WordListFilter struct {
+	lists map[string]map[string]bool
+}
+
+// NewWordListFilter loads the word lists in CONTENT_FILTER_WORDLIST_DIR, one
+// file per language named after its ISO 639-1 code (such as en.txt) with one
+// word per line, words in default.txt apply to every language
+func NewWordListFilter() *WordListFilter {
+	f := &WordListFilter{
+		lists: make(map[string]map[string]bool),
+	}
+	dir := viper.GetString("CONTENT_FILTER_WORDLIST_DIR")
+	if dir == "" {
+		return f
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		log.Println("Content filter error:", err)
+		return f
+	}
+	for _, path := range paths {
+		language := strings.ToLower(strings.TrimSuffix(filepath.Base(path), ".txt"))
+		words, err := readWordList(path)
+		if err != nil {
+			log.Println("Content filter error:", err)
+			continue
+		}
+		f.lists[language] = words
+	}
+	return f
+}
+
+func readWordList(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	words := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words[word] = true
+		}
+	}
+	return words, scanner.Err()
+}
+
+// listed reports whether a word is in the list of a language or the default list
+func (f *WordListFilter) listed(language string, word string) bool {
+	word = strings.ToLower(word)
+	return f.lists[language][word] || f.lists["default"][word]
+}
+
+// Check masks or rejects listed words
+func (f *WordListFilter) Check(ctx context.Context, message *Message) error {
+	if len(f.lists) == 0 {
+		return nil
+	}
+	var rejected bool
+	message.Text = wordPattern.ReplaceAllStringFunc(message.Text, func(word string) string {
+		if !f.listed(message.Language, word) {
+			return word
+		}
+		rejected = true
+		return strings.Repeat("*", len([]rune(word)))
+	})
+	if rejected && message.Strictness == Strict {
+		return errors.ErrMessageRejected
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package crud
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// Roles are the platform roles a user may be given
+var Roles = map[string]bool{
+	models.RoleMember:    true,
+	models.RoleModerator: true,
+	models.RoleAdmin:     true,
+}
+
+// roleRanks orders the platform roles, users are only suspended by someone
+// ranked above them
+var roleRanks = map[string]int{
+	models.RoleMember:    0,
+	models.RoleModerator: 1,
+	models.RoleAdmin:     2,
+}
+
+// SearchUser gets a page of users whose name, handle, email or phone number contain q
+func SearchUser(q string, n int) ([]*schemas.AdminUser, error) {
+	db := pgdb.GetDB()
+	pageSize := viper.GetInt("CLUB_PAGE_SIZE")
+	users := make([]*schemas.AdminUser, 0)
+	query := db.Model((*models.User)(nil)).
+		ColumnExpr("\"user\".\"id\", \"user\".\"username\", \"user\".\"handle\", \"user\".\"phone_no\", \"user\".\"email\", \"user\".\"role\", \"user\".\"suspended_until\"")
+	if q != "" {
+		pattern := "%" + q + "%"
+		query = query.Where("\"user\".\"username\" ILIKE ?0 OR \"user\".\"handle\" ILIKE ?0 OR \"user\".\"email\" ILIKE ?0 OR \"user\".\"phone_no\" ILIKE ?0", pattern)
+	}
+	err := query.Order("username ASC").
+		Offset((n - 1) * pageSize).
+		Limit(pageSize).
+		Select(&users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SearchAdminClub gets a page of every club, private and archived ones included, whose name or book contain q
+func SearchAdminClub(q string, n int) ([]*schemas.Club, error) {
+	db := pgdb.GetDB()
+	pageSize := viper.GetInt("CLUB_PAGE_SIZE")
+	clubs := make([]*schemas.Club, 0)
+	query := db.Model((*models.Club)(nil)).
+		ColumnExpr(clubColumns).
+		Join("LEFT JOIN users as u").
+		JoinOn("club.host_id = u.id")
+	if q != "" {
+		pattern := "%" + q + "%"
+		query = query.Where("club.club_name ILIKE ?0 OR club.book_title ILIKE ?0 OR club.book_author ILIKE ?0", pattern)
+	}
+	err := query.Order("club.club_name ASC").
+		Offset((n - 1) * pageSize).
+		Limit(pageSize).
+		Select(&clubs)
+	if err != nil {
+		return nil, err
+	}
+	return clubs, nil
+}
+
+// lockUser selects a user for update inside a transaction
+func lockUser(tx *pg.Tx, userID string) (*models.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	user := &models.User{
+		ID: uid,
+	}
+	err = tx.Model(user).WherePK().For("UPDATE").Select()
+	if err == pg.ErrNoRows {
+		return nil, errors.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetUserRole gives a user a platform role
+func SetUserRole(userID string, role string, audit *models.AuditLog) error {
+	db := pgdb.GetDB()
+	if !Roles[role] {
+		return errors.ErrInvalidRole
+	}
+	return db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		user, err := lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model((*models.User)(nil)).
+			Set("role = ?", role).
+			Where("id = ?", user.ID).
+			Update()
+		if err != nil {
+			return err
+		}
+		if audit != nil {
+			audit.TargetType = models.ReportUser
+			audit.TargetID = user.ID
+			audit.Before = map[string]interface{}{"role": user.Role}
+			audit.After = map[string]interface{}{"role": role}
+		}
+		return writeAuditLog(tx, audit)
+	})
+}
+
+// SetUserSuspension suspends a user until a given time, or lifts their suspension when until is nil
+func SetUserSuspension(actorID string, userID string, until *time.Time, audit *models.AuditLog) error {
+	db := pgdb.GetDB()
+	return db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		user, err := lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err = SuspendUser(tx, actorID, userID, until); err != nil {
+			return err
+		}
+		if audit != nil {
+			audit.TargetType = models.ReportUser
+			audit.TargetID = user.ID
+			audit.Before = map[string]interface{}{"suspended_until": user.SuspendedUntil}
+			audit.After = map[string]interface{}{"suspended_until": until}
+		}
+		return writeAuditLog(tx, audit)
+	})
+}
+
+// HideContent hides a chat message or comment from everyone, or restores it
+func HideContent(targetType string, targetID string, hidden bool, audit *models.AuditLog) error {
+	db := pgdb.GetDB()
+	var content models.Hideable
+	err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		wasHidden, txErr := contentHidden(tx, targetType, targetID)
+		if txErr != nil {
+			return txErr
+		}
+		content, txErr = SetContentHidden(tx, targetType, targetID, hidden)
+		if txErr != nil {
+			return txErr
+		}
+		if audit != nil {
+			tid, _ := uuid.Parse(targetID)
+			audit.TargetType = targetType
+			audit.TargetID = tid
+			audit.ClubID, _ = reportTargetClub(targetType, tid)
+			audit.Before = map[string]interface{}{"hidden": wasHidden}
+			audit.After = map[string]interface{}{"hidden": hidden}
+		}
+		return writeAuditLog(tx, audit)
+	})
+	if err != nil {
+		return err
+	}
+	publishHidden(content)
+	return nil
+}
+
+// TransferClubHost makes another member of a club its host
+func TransferClubHost(clubID string, hostID string, audit *models.AuditLog) (*models.Club, error) {
+	db := pgdb.GetDB()
+	cid, err := uuid.Parse(clubID)
+	if err != nil {
+		return nil, errors.ErrClubNotFound
+	}
+	hid, err := uuid.Parse(hostID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	club := &models.Club{
+		ID: cid,
+	}
+	err = db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		txErr := tx.Model(club).WherePK().For("UPDATE").Select()
+		if txErr == pg.ErrNoRows {
+			return errors.ErrClubNotFound
+		}
+		if txErr != nil {
+			return txErr
+		}
+		member, txErr := tx.Model((*models.ClubUser)(nil)).
+			Where("club_id = ?", cid).
+			Where("user_id = ?", hid).
+			Exists()
+		if txErr != nil {
+			return txErr
+		}
+		if !member {
+			return errors.ErrNotMember
+		}
+		previous := club.HostID
+		club.HostID = hid
+		_, txErr = tx.Model(club).Column("host_id").WherePK().Returning("*").Update()
+		if txErr != nil {
+			return txErr
+		}
+		if audit != nil {
+			audit.TargetType = models.ReportClub
+			audit.TargetID = cid
+			audit.ClubID = cid
+			audit.Before = map[string]interface{}{"host_id": previous}
+			audit.After = map[string]interface{}{"host_id": hid}
+		}
+		return writeAuditLog(tx, audit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = CreateNotification(&models.Notification{
+		UserID: hid,
+		ClubID: cid,
+		Type:   models.NotificationRoleChange,
+		Data: map[string]interface{}{
+			"clubname": club.ClubName,
+			"role":     "host",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return club, nil
+}
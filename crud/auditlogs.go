@@ -0,0 +1,43 @@
+package crud
+
+import (
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+)
+
+// writeAuditLog records an audit log entry in the transaction of the action
+// it describes so neither outlives the other, nil entries are skipped
+func writeAuditLog(tx orm.DB, audit *models.AuditLog) error {
+	if audit == nil {
+		return nil
+	}
+	_, err := tx.Model(audit).Returning("*").Insert()
+	return err
+}
+
+// ListAuditLog gets a page of the audit log newest first, only for one club if clubID is set
+func ListAuditLog(clubID string, n int) ([]*models.AuditLog, error) {
+	db := pgdb.GetDB()
+	pageSize := viper.GetInt("CLUB_PAGE_SIZE")
+	logs := make([]*models.AuditLog, 0)
+	q := db.Model(&logs)
+	if clubID != "" {
+		cid, err := uuid.Parse(clubID)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("club_id = ?", cid)
+	}
+	err := q.Order("time_created DESC").
+		Offset((n - 1) * pageSize).
+		Limit(pageSize).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
@@ -0,0 +1,113 @@
+package crud
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// notBlockedBy filters out rows whose user_id column belongs to someone the viewer blocked
+const notBlockedBy = "NOT EXISTS (SELECT * FROM user_blocks ub WHERE ub.user_id = ? AND ub.blocked_id = %s.user_id)"
+
+func parseUserBlock(userID string, blockedID string) (*models.UserBlock, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	bid, err := uuid.Parse(blockedID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserBlock{
+		UserID:    uid,
+		BlockedID: bid,
+	}, nil
+}
+
+// BlockUser creates a userblock record in the database or returns an error
+func BlockUser(userID string, blockedID string) (*models.UserBlock, error) {
+	db := pgdb.GetDB()
+	block, err := parseUserBlock(userID, blockedID)
+	if err != nil {
+		return nil, err
+	}
+	if block.UserID == block.BlockedID {
+		return nil, errors.ErrSelfBlock
+	}
+	_, err = db.Model(block).
+		Where("user_id = ?user_id and blocked_id = ?blocked_id").
+		OnConflict("DO NOTHING").
+		Returning("*").
+		SelectOrInsert()
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// UnblockUser deletes a userblock record from the database
+func UnblockUser(userID string, blockedID string) error {
+	db := pgdb.GetDB()
+	block, err := parseUserBlock(userID, blockedID)
+	if err != nil {
+		return err
+	}
+	_, err = db.Model(block).
+		Where("user_id = ?user_id and blocked_id = ?blocked_id").
+		Delete()
+	return err
+}
+
+// ListBlockedUser gets the public details of the users a user has blocked
+func ListBlockedUser(userID string) ([]*schemas.PublicUser, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*schemas.PublicUser, 0)
+	err = db.Model((*models.User)(nil)).
+		ColumnExpr(publicUserColumns).
+		Join("INNER JOIN user_blocks as ub").
+		JoinOn("ub.blocked_id = \"user\".id").
+		Where("ub.user_id = ?", uid).
+		Order("ub.time_created DESC").
+		Select(&users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// ListBlockedID gets the ids of the users a user has blocked
+func ListBlockedID(userID string) ([]string, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0)
+	err = db.Model((*models.UserBlock)(nil)).
+		Column("blocked_id").
+		Where("user_id = ?", uid).
+		Select(&ids)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// IsBlocked checks whether userID has blocked otherID
+func IsBlocked(userID string, otherID string) (bool, error) {
+	db := pgdb.GetDB()
+	block, err := parseUserBlock(userID, otherID)
+	if err != nil {
+		return false, err
+	}
+	return db.Model((*models.UserBlock)(nil)).
+		Where("user_id = ? and blocked_id = ?", block.UserID, block.BlockedID).
+		Exists()
+}
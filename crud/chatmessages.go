@@ -7,6 +7,7 @@ import (
 	"github.com/Krishap-s/keats-backend/models"
 	"github.com/Krishap-s/keats-backend/pgdb"
 	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/go-pg/pg/v10"
 	"github.com/google/uuid"
 )
 
@@ -21,11 +22,12 @@ func CreateChatMessage(objIn *schemas.ChatMessageCreate) (*models.ChatMessage, e
 	if err != nil {
 		return nil, err
 	}
-	if len(objIn.Message) > 150 {
-		return nil, fmt.Errorf("max string length")
+	text, err := filterMessage(cid, uid, objIn.Message)
+	if err != nil {
+		return nil, err
 	}
 	chatmessage := &models.ChatMessage{
-		Message:     objIn.Message,
+		Message:     text,
 		ClubID:      cid,
 		UserID:      uid,
 		TimeCreated: time.Now(),
@@ -38,12 +40,15 @@ func CreateChatMessage(objIn *schemas.ChatMessageCreate) (*models.ChatMessage, e
 	return chatmessage, nil
 }
 
-// GetChatMessage gets chatmessages from a room or returns an error
-func GetChatMessage(cid string) ([]*schemas.ChatMessage, error) {
+// GetChatMessage gets chatmessages from a room, leaving out those sent by users
+// the viewer has blocked, or returns an error
+func GetChatMessage(cid string, viewerID string) ([]*schemas.ChatMessage, error) {
 	db := pgdb.GetDB()
 	var chatmessages []*schemas.ChatMessage
 	err := db.Model((*models.ChatMessage)(nil)).
 		Where("club_id = ?", cid).
+		Where(fmt.Sprintf(notBlockedBy, "chat_message"), viewerID).
+		Where("NOT chat_message.hidden").
 		Order("time_created ASC").
 		Select(&chatmessages)
 	if err != nil {
@@ -55,14 +60,17 @@ func GetChatMessage(cid string) ([]*schemas.ChatMessage, error) {
 // AddChatMessageLike increments likes field of chatmessage
 func AddChatMessageLike(id string) error {
 	db := pgdb.GetDB()
-	chatmessage := models.ChatMessage{}
-	_, err := db.Model(&chatmessage).
+	// Updating through a nil model keeps the moderation hook from firing
+	res, err := db.Model((*models.ChatMessage)(nil)).
 		Set("likes = likes + 1").
 		Where("id = ?", id).
-		Returning("id").
-		UpdateNotZero()
+		Where("NOT hidden").
+		Update()
 	if err != nil {
 		return err
 	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
 	return nil
 }
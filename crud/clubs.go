@@ -1,15 +1,41 @@
 package crud
 
 import (
-	"fmt"
+	"context"
+	"encoding/base64"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Krishap-s/keats-backend/contentfilter"
+	"github.com/Krishap-s/keats-backend/errors"
 	"github.com/Krishap-s/keats-backend/models"
 	"github.com/Krishap-s/keats-backend/pgdb"
 	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
+// clubColumns selects the columns of schemas.Club from a club joined with its host as u
+const clubColumns = "club.id,club.club_name,club.club_pic,club.file_url,club.page_no,club.private,club.page_sync,club.host_id,u.id as host_id,u.username as host_name,u.profile_pic as host_profile_pic,club.max_members,club.archived,club.book_title,club.book_author,club.genre,club.language,club.filter_strictness,ARRAY(SELECT t.name FROM club_tags ct INNER JOIN tags t ON t.id = ct.tag_id WHERE ct.club_id = club.id ORDER BY t.name) AS tags"
+
+// clubSearchVector is the full-text document of a club, backed by the clubs_search_idx index
+const clubSearchVector = "to_tsvector('simple', coalesce(club.club_name, '') || ' ' || coalesce(club.book_title, '') || ' ' || coalesce(club.book_author, ''))"
+
+const clubMemberCount = "(SELECT count(*) FROM club_users cu WHERE cu.club_id = club.id)"
+
+const clubLastActivity = "COALESCE((SELECT max(cm.time_created) FROM chat_messages cm WHERE cm.club_id = club.id), club.time_created)"
+
+// clubSorts maps the sort orders of the discovery API to their SQL expression and type
+var clubSorts = map[string][2]string{
+	"recent":   {"club.time_created", "timestamptz"},
+	"activity": {clubLastActivity, "timestamptz"},
+	"members":  {clubMemberCount, "bigint"},
+}
+
 func parseClubUser(clubID string, userID string) (*models.ClubUser, error) {
 	cid, err := uuid.Parse(clubID)
 	if err != nil {
@@ -26,6 +52,85 @@ func parseClubUser(clubID string, userID string) (*models.ClubUser, error) {
 	return clubuser, nil
 }
 
+// admitWaitlist moves users from the waitlist of a club into the club while it has free seats
+func admitWaitlist(clubID uuid.UUID) error {
+	db := pgdb.GetDB()
+	var admitted []*models.ClubWaitlist
+	err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		var txErr error
+		admitted, txErr = admitWaitlistTx(tx, clubID)
+		return txErr
+	})
+	if err != nil {
+		return err
+	}
+	return notifyAdmitted(admitted)
+}
+
+// admitWaitlistTx admits users from the waitlist inside a transaction and
+// returns who got in so they can be told once it commits
+func admitWaitlistTx(tx orm.DB, clubID uuid.UUID) ([]*models.ClubWaitlist, error) {
+	club := &models.Club{
+		ID: clubID,
+	}
+	err := tx.Model(club).WherePK().For("UPDATE").Select()
+	if err != nil {
+		return nil, err
+	}
+	q := tx.Model((*models.ClubWaitlist)(nil)).
+		Where("club_id = ?", clubID).
+		Order("time_created ASC")
+	if club.MaxMembers > 0 {
+		var count int
+		count, err = tx.Model((*models.ClubUser)(nil)).Where("club_id = ?", clubID).Count()
+		if err != nil {
+			return nil, err
+		}
+		if count >= club.MaxMembers {
+			return nil, nil
+		}
+		q = q.Limit(club.MaxMembers - count)
+	}
+	var waitlist []*models.ClubWaitlist
+	if err = q.Select(&waitlist); err != nil {
+		return nil, err
+	}
+	for _, waiting := range waitlist {
+		clubuser := &models.ClubUser{
+			ClubID: waiting.ClubID,
+			UserID: waiting.UserID,
+		}
+		_, err = tx.Model(clubuser).OnConflict("DO NOTHING").Insert()
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Model(waiting).WherePK().Delete()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return waitlist, nil
+}
+
+// notifyAdmitted tells users admitted from a waitlist that they got a seat
+func notifyAdmitted(admitted []*models.ClubWaitlist) error {
+	return createNotifications(admittedNotifications(admitted))
+}
+
+// admittedNotifications are the notifications telling users admitted from a
+// waitlist that they got a seat
+func admittedNotifications(admitted []*models.ClubWaitlist) []*models.Notification {
+	notifications := make([]*models.Notification, 0, len(admitted))
+	for _, waiting := range admitted {
+		notifications = append(notifications, &models.Notification{
+			UserID: waiting.UserID,
+			ClubID: waiting.ClubID,
+			Type:   models.NotificationWaitlistJoin,
+		})
+	}
+	return notifications
+}
+
 // CreateUser creates a club in the database or returns an error
 func CreateClub(objIn *schemas.ClubCreate) (*models.Club, error) {
 	db := pgdb.GetDB()
@@ -33,106 +138,400 @@ func CreateClub(objIn *schemas.ClubCreate) (*models.Club, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(objIn.ClubName) > 30 || len(objIn.ClubPic) > 100 {
-		return nil, fmt.Errorf("max string length")
-	}
-	club := &models.Club{
-		ClubName: objIn.ClubName,
-		ClubPic:  objIn.ClubPic,
-		PageSync: objIn.PageSync,
-		FileURL:  objIn.FileURL,
-		Private:  objIn.Private,
-		PageNo:   objIn.PageNo,
-		HostID:   uid,
+	if objIn.MaxMembers < 0 {
+		return nil, errors.ErrInvalidMaxMembers
 	}
-
-	_, err = db.Model(club).
-		Returning("*").
-		Insert()
+	tags, err := normalizeTags(objIn.Tags)
 	if err != nil {
 		return nil, err
 	}
-
-	clubuser := &models.ClubUser{
-		ClubID: club.ID,
-		UserID: uid,
+	club := &models.Club{
+		MaxMembers: objIn.MaxMembers,
+		BookTitle:  objIn.BookTitle,
+		BookAuthor: objIn.BookAuthor,
+		Genre:      objIn.Genre,
+		Language:   objIn.Language,
+		ClubName:   objIn.ClubName,
+		ClubPic:    objIn.ClubPic,
+		PageSync:   objIn.PageSync,
+		FileURL:    objIn.FileURL,
+		Private:    objIn.Private,
+		PageNo:     objIn.PageNo,
+		HostID:     uid,
 	}
-	_, err = db.Model(clubuser).Returning("*").Insert()
+
+	// The club is only created along with its host membership and tags
+	err = db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		_, txErr := tx.Model(club).
+			Returning("*").
+			Insert()
+		if txErr != nil {
+			return txErr
+		}
+		clubuser := &models.ClubUser{
+			ClubID: club.ID,
+			UserID: uid,
+		}
+		_, txErr = tx.Model(clubuser).Returning("*").Insert()
+		if txErr != nil {
+			return txErr
+		}
+		return setClubTags(tx, club.ID, tags)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return club, nil
 }
 
-// UpdateClub updates a club in the database or returns an error
-func UpdateClub(objIn *schemas.ClubUpdate) (*models.Club, error) {
+// UpdateClub updates a club in the database or returns an error, the fields
+// that changed are recorded in the audit log in the same transaction
+func UpdateClub(objIn *schemas.ClubUpdate, audit *models.AuditLog) (*models.Club, error) {
 	db := pgdb.GetDB()
 	uid, err := uuid.Parse(objIn.ID)
 	if err != nil {
 		return nil, err
 	}
-	if len(objIn.ClubName) > 30 || len(objIn.ClubPic) > 100 {
-		return nil, fmt.Errorf("max string length")
+	if objIn.MaxMembers != nil && *objIn.MaxMembers < 0 {
+		return nil, errors.ErrInvalidMaxMembers
+	}
+	tags, err := normalizeTags(objIn.Tags)
+	if err != nil {
+		return nil, err
 	}
 	club := &models.Club{
-		ID:       uid,
-		ClubName: objIn.ClubName,
-		ClubPic:  objIn.ClubPic,
-		FileURL:  objIn.FileURL,
-		PageNo:   objIn.PageNo,
-	}
-
-	_, err = db.Model(club).
-		Column("club_name").
-		Column("file_url").
-		Column("club_pic").
-		Column("page_no").
-		Returning("*").
-		WherePK().
-		UpdateNotZero()
+		ID:         uid,
+		ClubName:   objIn.ClubName,
+		ClubPic:    objIn.ClubPic,
+		FileURL:    objIn.FileURL,
+		PageNo:     objIn.PageNo,
+		BookTitle:  objIn.BookTitle,
+		BookAuthor: objIn.BookAuthor,
+		Genre:      objIn.Genre,
+		Language:   objIn.Language,
+	}
+
+	err = db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		previous := &models.Club{
+			ID: uid,
+		}
+		txErr := tx.Model(previous).WherePK().For("UPDATE").Select()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model(club).
+			Column("club_name").
+			Column("file_url").
+			Column("club_pic").
+			Column("page_no").
+			Column("book_title").
+			Column("book_author").
+			Column("genre").
+			Column("language").
+			Returning("*").
+			WherePK().
+			UpdateNotZero()
+		if txErr != nil {
+			return txErr
+		}
+
+		// Zero removes the cap so it cannot go through UpdateNotZero
+		if objIn.MaxMembers != nil {
+			_, txErr = tx.Model(club).
+				Set("max_members = ?", *objIn.MaxMembers).
+				Returning("*").
+				WherePK().
+				Update()
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		before, after := clubChanges(previous, club)
+		// Tags are left untouched unless a new list was sent
+		if objIn.Tags != nil {
+			var previousTags []string
+			_, txErr = tx.Query(pg.Array(&previousTags), "SELECT coalesce(array_agg(t.name ORDER BY t.name), '{}') FROM club_tags ct INNER JOIN tags t ON t.id = ct.tag_id WHERE ct.club_id = ?", uid)
+			if txErr != nil {
+				return txErr
+			}
+			if txErr = setClubTags(tx, uid, tags); txErr != nil {
+				return txErr
+			}
+			before["tags"] = previousTags
+			after["tags"] = tags
+		}
+		if audit != nil {
+			audit.TargetType = models.ReportClub
+			audit.TargetID = uid
+			audit.ClubID = uid
+			audit.Before = before
+			audit.After = after
+		}
+		return writeAuditLog(tx, audit)
+	})
 	if err != nil {
 		return nil, err
 	}
+	if objIn.MaxMembers != nil {
+		if err = admitWaitlist(uid); err != nil {
+			return nil, err
+		}
+	}
 
 	return club, nil
 }
 
-// TogglePrivate toggles the private status of a club
-func TogglePrivate(clubID string) error {
+// clubChanges gets the settings of a club that differ between two versions of it
+func clubChanges(previous *models.Club, updated *models.Club) (map[string]interface{}, map[string]interface{}) {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	fields := []struct {
+		name string
+		old  interface{}
+		new  interface{}
+	}{
+		{"clubname", previous.ClubName, updated.ClubName},
+		{"club_pic", previous.ClubPic, updated.ClubPic},
+		{"file_url", previous.FileURL, updated.FileURL},
+		{"page_no", previous.PageNo, updated.PageNo},
+		{"book_title", previous.BookTitle, updated.BookTitle},
+		{"book_author", previous.BookAuthor, updated.BookAuthor},
+		{"genre", previous.Genre, updated.Genre},
+		{"language", previous.Language, updated.Language},
+		{"max_members", previous.MaxMembers, updated.MaxMembers},
+	}
+	for _, field := range fields {
+		if field.old != field.new {
+			before[field.name] = field.old
+			after[field.name] = field.new
+		}
+	}
+	return before, after
+}
+
+// toggleClubColumn flips a boolean setting of a club and records the change
+// in the audit log in the same transaction
+func toggleClubColumn(clubID string, column string, audit *models.AuditLog) error {
 	db := pgdb.GetDB()
-	_, err := db.Model((*models.Club)(nil)).
-		Set("private = NOT private").
-		Where("id = ?", clubID).
-		Update()
+	cid, err := uuid.Parse(clubID)
 	if err != nil {
-		return err
+		return errors.ErrClubNotFound
 	}
-	return nil
+	return db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		var previous bool
+		txErr := tx.Model((*models.Club)(nil)).
+			ColumnExpr("?", pg.Ident(column)).
+			Where("id = ?", cid).
+			For("UPDATE").
+			Select(&previous)
+		if txErr == pg.ErrNoRows {
+			return errors.ErrClubNotFound
+		}
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.Club)(nil)).
+			Set("?0 = NOT ?0", pg.Ident(column)).
+			Where("id = ?", cid).
+			Update()
+		if txErr != nil {
+			return txErr
+		}
+		if audit != nil {
+			audit.TargetType = models.ReportClub
+			audit.TargetID = cid
+			audit.ClubID = cid
+			audit.Before = map[string]interface{}{column: previous}
+			audit.After = map[string]interface{}{column: !previous}
+		}
+		return writeAuditLog(tx, audit)
+	})
+}
+
+// TogglePrivate toggles the private status of a club
+func TogglePrivate(clubID string, audit *models.AuditLog) error {
+	return toggleClubColumn(clubID, "private", audit)
 }
 
 // ToggleSync toggles the page sync feature of a club
-func ToggleSync(clubID string) error {
+func ToggleSync(clubID string, audit *models.AuditLog) error {
+	return toggleClubColumn(clubID, "page_sync", audit)
+}
+
+// SetClubFilterStrictness sets how strictly chat messages and comments of a club are filtered
+func SetClubFilterStrictness(clubID string, strictness string, audit *models.AuditLog) error {
 	db := pgdb.GetDB()
-	_, err := db.Model((*models.Club)(nil)).
-		Set("page_sync = NOT page_sync").
-		Where("id = ?", clubID).
-		Update()
+	if !contentfilter.Strictnesses[strictness] {
+		return errors.ErrInvalidStrictness
+	}
+	cid, err := uuid.Parse(clubID)
 	if err != nil {
-		return err
+		return errors.ErrClubNotFound
 	}
-	return nil
+	return db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		var previous string
+		txErr := tx.Model((*models.Club)(nil)).
+			Column("filter_strictness").
+			Where("id = ?", cid).
+			For("UPDATE").
+			Select(&previous)
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.Club)(nil)).
+			Set("filter_strictness = ?", strictness).
+			Where("id = ?", cid).
+			Update()
+		if txErr != nil {
+			return txErr
+		}
+		if audit != nil {
+			audit.TargetType = models.ReportClub
+			audit.TargetID = cid
+			audit.ClubID = cid
+			audit.Before = map[string]interface{}{"filter_strictness": previous}
+			audit.After = map[string]interface{}{"filter_strictness": strictness}
+		}
+		return writeAuditLog(tx, audit)
+	})
 }
 
-// ListClub gets all non-private clubs from database or returns an error
-func ListClub(userID string, n int) ([]*schemas.Club, error) {
+// filterMessage runs the content filter pipeline on text sent by a user to a
+// club and returns the text to be persisted
+func filterMessage(clubID uuid.UUID, userID uuid.UUID, text string) (string, error) {
+	db := pgdb.GetDB()
+	club := &models.Club{
+		ID: clubID,
+	}
+	err := db.Model(club).Column("language", "filter_strictness").WherePK().Select()
+	if err != nil {
+		return "", err
+	}
+	message := &contentfilter.Message{
+		ClubID:     clubID.String(),
+		UserID:     userID.String(),
+		Language:   club.Language,
+		Strictness: club.FilterStrictness,
+		Text:       text,
+	}
+	if err = contentfilter.GetPipeline().Check(context.Background(), message); err != nil {
+		return "", err
+	}
+	return message.Text, nil
+}
+
+// ToggleArchive toggles the archived (read-only) status of a club
+func ToggleArchive(clubID string, audit *models.AuditLog) error {
+	return toggleClubColumn(clubID, "archived", audit)
+}
+
+// IsClubArchived reports whether a club has been archived
+func IsClubArchived(clubID string) (bool, error) {
+	db := pgdb.GetDB()
+	cid, err := uuid.Parse(clubID)
+	if err != nil {
+		return false, err
+	}
+	club := &models.Club{
+		ID: cid,
+	}
+	err = db.Model(club).Column("archived").WherePK().Select()
+	if err != nil {
+		return false, err
+	}
+	return club.Archived, nil
+}
+
+// DeleteClub deletes a club along with its members, waitlist, comments and chat
+// and returns the deleted club so that its stored files can be cleaned up, the
+// audit log entry is recorded in the same transaction when given
+func DeleteClub(clubID string, audit *models.AuditLog) (*models.Club, error) {
+	db := pgdb.GetDB()
+	cid, err := uuid.Parse(clubID)
+	if err != nil {
+		return nil, errors.ErrClubNotFound
+	}
+	club := &models.Club{
+		ID: cid,
+	}
+	err = db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		txErr := tx.Model(club).WherePK().For("UPDATE").Select()
+		if txErr == pg.ErrNoRows {
+			return errors.ErrClubNotFound
+		}
+		if txErr != nil {
+			return txErr
+		}
+		related := []interface{}{
+			(*models.ClubUser)(nil),
+			(*models.ClubWaitlist)(nil),
+			(*models.ClubTag)(nil),
+			(*models.ClubScore)(nil),
+			(*models.Comment)(nil),
+			(*models.ChatMessage)(nil),
+			(*models.NotificationSetting)(nil),
+		}
+		for _, model := range related {
+			_, txErr = tx.Model(model).Where("club_id = ?", cid).Delete()
+			if txErr != nil {
+				return txErr
+			}
+		}
+		_, txErr = tx.Model(club).WherePK().Delete()
+		if txErr != nil {
+			return txErr
+		}
+		if audit != nil {
+			audit.TargetType = models.ReportClub
+			audit.TargetID = cid
+			audit.ClubID = cid
+			audit.Before = map[string]interface{}{
+				"clubname": club.ClubName,
+				"host_id":  club.HostID,
+			}
+		}
+		return writeAuditLog(tx, audit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Members are only disconnected once the deletion is committed
+	if err = club.PublishDeleted(context.Background()); err != nil {
+		log.Println("Publish error:", err)
+	}
+	return club, nil
+}
+
+// ListAbandonedClubs gets clubs that have neither a host nor any members left
+func ListAbandonedClubs() ([]*models.Club, error) {
+	db := pgdb.GetDB()
+	var clubs []*models.Club
+	err := db.Model(&clubs).
+		Where("host_id = ?", uuid.Nil).
+		Where("NOT EXISTS (SELECT * FROM club_users cu WHERE cu.club_id = club.id)").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return clubs, nil
+}
+
+// ListClub gets all non-private clubs, optionally labelled with tag, from database or returns an error
+func ListClub(userID string, n int, tag string) ([]*schemas.Club, error) {
 	db := pgdb.GetDB()
 	pageSize := viper.GetInt("CLUB_PAGE_SIZE")
 	var clubs []*schemas.Club
-	err := db.Model((*models.Club)(nil)).
-		ColumnExpr("club.id,club.club_name,club.club_pic,club.file_url,club.page_no,club.private,club.host_id,u.id as host_id,u.username as host_name,u.profile_pic as host_profile_pic").
+	q := db.Model((*models.Club)(nil))
+	if tag != "" {
+		q = q.Where(clubHasTag, NormalizeTag(tag))
+	}
+	err := q.
+		ColumnExpr(clubColumns).
 		Join("INNER JOIN users as u").
 		JoinOn("club.host_id = u.id").
 		Where("private = false").
+		Where("club.archived = false").
 		Where("NOT EXISTS (SELECT * FROM club_users cu WHERE cu.club_id = club.id AND cu.user_id = ?)", userID).
 		Offset((n - 1) * pageSize).
 		Limit(pageSize).
@@ -143,6 +542,112 @@ func ListClub(userID string, n int) ([]*schemas.Club, error) {
 	return clubs, nil
 }
 
+// encodeClubCursor encodes the sort value and id of the last club of a page
+func encodeClubCursor(value string, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(value + "|" + id))
+}
+
+// decodeClubCursor decodes a cursor produced by encodeClubCursor
+func decodeClubCursor(cursor string) (string, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", errors.ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return "", "", errors.ErrInvalidCursor
+	}
+	if _, err = uuid.Parse(parts[1]); err != nil {
+		return "", "", errors.ErrInvalidCursor
+	}
+	return parts[0], parts[1], nil
+}
+
+// SearchClub gets a page of public clubs the user has not joined, filtered,
+// sorted and paginated by keyset as described by objIn
+func SearchClub(userID string, objIn *schemas.ClubSearch) (*schemas.ClubPage, error) {
+	db := pgdb.GetDB()
+	pageSize := viper.GetInt("CLUB_PAGE_SIZE")
+	if objIn.Sort == "" {
+		objIn.Sort = "recent"
+	}
+	sort, ok := clubSorts[objIn.Sort]
+	if !ok {
+		return nil, errors.ErrInvalidSort
+	}
+	sortExpr, sortType := sort[0], sort[1]
+
+	filter := func(q *orm.Query) (*orm.Query, error) {
+		q = q.Where("club.private = false").
+			Where("club.archived = false").
+			Where("NOT EXISTS (SELECT * FROM club_users cu WHERE cu.club_id = club.id AND cu.user_id = ?)", userID)
+		if objIn.Query != "" {
+			q = q.Where(clubSearchVector+" @@ plainto_tsquery('simple', ?)", objIn.Query)
+		}
+		if objIn.Genre != "" {
+			q = q.Where("lower(club.genre) = lower(?)", objIn.Genre)
+		}
+		if objIn.Language != "" {
+			q = q.Where("lower(club.language) = lower(?)", objIn.Language)
+		}
+		if objIn.Tag != "" {
+			q = q.Where(clubHasTag, NormalizeTag(objIn.Tag))
+		}
+		return q, nil
+	}
+
+	total, err := db.Model((*models.Club)(nil)).
+		Join("INNER JOIN users as u").
+		JoinOn("club.host_id = u.id").
+		Apply(filter).
+		Count()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Model((*models.Club)(nil)).
+		ColumnExpr(clubColumns).
+		ColumnExpr(clubMemberCount + " AS member_count").
+		ColumnExpr(clubLastActivity + " AS last_activity").
+		ColumnExpr("club.time_created").
+		Join("INNER JOIN users as u").
+		JoinOn("club.host_id = u.id").
+		Apply(filter)
+	if objIn.Cursor != "" {
+		value, id, err := decodeClubCursor(objIn.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("("+sortExpr+", club.id) < (?::"+sortType+", ?::uuid)", value, id)
+	}
+	clubs := make([]*schemas.ClubDiscovery, 0)
+	err = q.OrderExpr(sortExpr + " DESC, club.id DESC").
+		Limit(pageSize).
+		Select(&clubs)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &schemas.ClubPage{
+		Clubs: clubs,
+		Total: total,
+	}
+	if len(clubs) == pageSize {
+		last := clubs[len(clubs)-1]
+		var value string
+		switch objIn.Sort {
+		case "members":
+			value = strconv.Itoa(last.MemberCount)
+		case "activity":
+			value = last.LastActivity.Format(time.RFC3339Nano)
+		default:
+			value = last.TimeCreated.Format(time.RFC3339Nano)
+		}
+		page.NextCursor = encodeClubCursor(value, last.ID)
+	}
+	return page, nil
+}
+
 // GetClub gets a club from the database or returns an error
 func GetClub(id string) (*schemas.Club, error) {
 	db := pgdb.GetDB()
@@ -155,7 +660,7 @@ func GetClub(id string) (*schemas.Club, error) {
 		ID: cid,
 	}
 	err = db.Model(club).
-		ColumnExpr("club.id,club.club_name,club.club_pic,club.file_url,club.page_no,club.private,club.host_id,u.id as host_id,u.username as host_name,u.profile_pic as host_profile_pic").
+		ColumnExpr(clubColumns).
 		Join("INNER JOIN users as u").
 		JoinOn("club.host_id = u.id").
 		WherePK().
@@ -166,51 +671,160 @@ func GetClub(id string) (*schemas.Club, error) {
 	return res, nil
 }
 
-// CreateClubUser creates a clubuser record in the database
+// CreateClubUser creates a clubuser record in the database, or puts the user
+// on the waitlist of the club if it is full
 func CreateClubUser(clubID string, userID string) (*models.ClubUser, error) {
 	db := pgdb.GetDB()
 	clubuser, err := parseClubUser(clubID, userID)
 	if err != nil {
 		return nil, err
 	}
-	_, err = db.Model(clubuser).Returning("*").Insert()
+	full := false
+	err = db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		// Lock the club row so concurrent joins are counted one after another
+		club := &models.Club{
+			ID: clubuser.ClubID,
+		}
+		txErr := tx.Model(club).WherePK().For("UPDATE").Select()
+		if txErr != nil {
+			return txErr
+		}
+		if club.MaxMembers > 0 {
+			var count int
+			count, txErr = tx.Model((*models.ClubUser)(nil)).Where("club_id = ?", club.ID).Count()
+			if txErr != nil {
+				return txErr
+			}
+			if count >= club.MaxMembers {
+				var isMember bool
+				isMember, txErr = tx.Model((*models.ClubUser)(nil)).
+					Where("club_id = ? and user_id = ?", clubuser.ClubID, clubuser.UserID).
+					Exists()
+				if txErr != nil {
+					return txErr
+				}
+				if isMember {
+					return errors.ErrAlreadyMember
+				}
+				full = true
+				waiting := &models.ClubWaitlist{
+					ClubID: clubuser.ClubID,
+					UserID: clubuser.UserID,
+				}
+				_, txErr = tx.Model(waiting).OnConflict("DO NOTHING").Insert()
+				return txErr
+			}
+		}
+		_, txErr = tx.Model(clubuser).Returning("*").Insert()
+		return txErr
+	})
 	if err != nil {
 		return nil, err
 	}
-	return clubuser, err
+	if full {
+		return nil, errors.ErrClubFull
+	}
+	return clubuser, nil
 }
 
-// GetClubUser get clubuser records from database
-func GetClubUser(clubID string) ([]*models.User, error) {
+// DeleteClubWaitlist removes a user from the waitlist of a club
+func DeleteClubWaitlist(clubID string, userID string) error {
+	db := pgdb.GetDB()
+	clubuser, err := parseClubUser(clubID, userID)
+	if err != nil {
+		return err
+	}
+	res, err := db.Model((*models.ClubWaitlist)(nil)).
+		Where("club_id = ? and user_id = ?", clubuser.ClubID, clubuser.UserID).
+		Delete()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
+
+// GetClubUser get the public details of clubuser records from database
+func GetClubUser(clubID string) ([]*schemas.PublicUser, error) {
 	db := pgdb.GetDB()
 	cid, err := uuid.Parse(clubID)
 	if err != nil {
 		return nil, err
 	}
-	var users []*models.User
-	err = db.Model(&users).
+	var users []*schemas.PublicUser
+	err = db.Model((*models.User)(nil)).
+		ColumnExpr(publicUserColumns).
 		Join("INNER JOIN club_users as cu").
 		JoinOn("cu.user_id = \"user\".id").
 		Where("cu.club_id = ?", cid).
-		Select()
+		Select(&users)
 	if err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-// DeleteClubUser deletes clubuser record from database
-func DeleteClubUser(clubID string, userID string) (*models.ClubUser, error) {
+// RemoveClubUser removes a member from a club, the removal and any host change
+// it causes are recorded in the audit log in the same transaction
+func RemoveClubUser(clubID string, userID string, audit *models.AuditLog) (*models.ClubUser, error) {
 	db := pgdb.GetDB()
-	clubuser, err := parseClubUser(clubID, userID)
+	var clubuser *models.ClubUser
+	var notifications []*models.Notification
+	err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		var txErr error
+		clubuser, notifications, txErr = DeleteClubUser(tx, clubID, userID, audit)
+		return txErr
+	})
 	if err != nil {
 		return nil, err
 	}
+	return clubuser, createNotifications(notifications)
+}
+
+// DeleteClubUser deletes clubuser record from db, which should be a
+// transaction, handing the freed seat to the waitlist and the club to another
+// member when its host leaves. The removal and any host change are recorded
+// in the audit log, and the notifications they call for are returned to be
+// created once the transaction is committed.
+func DeleteClubUser(db orm.DB, clubID string, userID string, audit *models.AuditLog) (*models.ClubUser, []*models.Notification, error) {
+	clubuser, err := parseClubUser(clubID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
 	cid := clubuser.ClubID
 	uid := clubuser.UserID
+	club := &models.Club{
+		ID: cid,
+	}
+	err = db.Model(club).WherePK().For("UPDATE").Select()
+	if err != nil {
+		return nil, nil, err
+	}
 	_, err = db.Model(clubuser).Where("user_id = ?user_id and club_id = ?club_id").Returning("*").Delete()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	// Hand the freed seat to the waitlist before picking a new host
+	admitted, err := admitWaitlistTx(db, cid)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifications := admittedNotifications(admitted)
+	if audit != nil {
+		audit.TargetType = models.ReportUser
+		audit.TargetID = uid
+		audit.ClubID = cid
+		audit.Before = map[string]interface{}{"member": true}
+		audit.After = map[string]interface{}{"member": false}
+		if err = writeAuditLog(db, audit); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Reset Host ID to someone else if host themselves is leaving
+	if club.HostID != uid {
+		return clubuser, notifications, nil
 	}
 	var users []*models.User
 	err = db.Model(&users).
@@ -220,27 +834,44 @@ func DeleteClubUser(clubID string, userID string) (*models.ClubUser, error) {
 		Where("cu.club_id = ?", cid).
 		Select()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	// Reset Host ID to someone else if host themselves is leaving
-	club := &models.Club{
-		ID: cid,
+	if len(users) != 0 {
+		club.HostID = users[0].ID
+	} else {
+		club.HostID = uuid.Nil
+		club.Private = true
 	}
-	err = db.Model(club).WherePK().Select()
+	_, err = db.Model(club).WherePK().Update()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	if club.HostID == uid {
-		if len(users) != 0 {
-			club.HostID = users[0].ID
-		} else {
-			club.HostID = uuid.Nil
-			club.Private = true
-		}
-		_, err = db.Model(club).WherePK().Update()
-		if err != nil {
-			return nil, err
-		}
+	transfer := &models.AuditLog{
+		ActorID:    uid,
+		Action:     "transfer_host",
+		TargetType: models.ReportClub,
+		TargetID:   cid,
+		ClubID:     cid,
+		Before:     map[string]interface{}{"host_id": uid},
+		After:      map[string]interface{}{"host_id": club.HostID},
+	}
+	if audit != nil {
+		transfer.ActorID = audit.ActorID
+		transfer.RequestID = audit.RequestID
+	}
+	if err = writeAuditLog(db, transfer); err != nil {
+		return nil, nil, err
+	}
+	if club.HostID != uuid.Nil {
+		notifications = append(notifications, &models.Notification{
+			UserID: club.HostID,
+			ClubID: cid,
+			Type:   models.NotificationRoleChange,
+			Data: map[string]interface{}{
+				"clubname": club.ClubName,
+				"role":     "host",
+			},
+		})
 	}
-	return clubuser, err
+	return clubuser, notifications, nil
 }
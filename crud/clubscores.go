@@ -0,0 +1,112 @@
+package crud
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// Weights of each kind of recent activity in the trending score of a club
+const (
+	messageWeight = 1.0
+	commentWeight = 2.0
+	joinWeight    = 5.0
+	overlapWeight = 10.0
+)
+
+// clubOverlap counts the members of a club who share another club with the user
+const clubOverlap = `(SELECT count(DISTINCT cu.user_id) FROM club_users cu
+	WHERE cu.club_id = club.id AND cu.user_id <> ?0 AND cu.user_id IN (
+		SELECT mate.user_id FROM club_users mate INNER JOIN club_users mine ON mine.club_id = mate.club_id
+		WHERE mine.user_id = ?0))`
+
+// RankClubs recomputes the trending scores of all clubs from activity since the given time
+func RankClubs(since time.Time) error {
+	db := pgdb.GetDB()
+	_, err := db.Exec(`INSERT INTO club_scores (club_id, messages, comments, joins, score, time_updated)
+		SELECT s.club_id, s.messages, s.comments, s.joins, s.messages * ?1 + s.comments * ?2 + s.joins * ?3, now()
+		FROM (SELECT c.id AS club_id,
+			(SELECT count(*) FROM chat_messages m WHERE m.club_id = c.id AND m.time_created > ?0) AS messages,
+			(SELECT count(*) FROM comments cm WHERE cm.club_id = c.id AND cm.time_created > ?0) AS comments,
+			(SELECT count(*) FROM club_users cu WHERE cu.club_id = c.id AND cu.time_created > ?0) AS joins
+			FROM clubs c) s
+		ON CONFLICT (club_id) DO UPDATE SET
+			messages = EXCLUDED.messages,
+			comments = EXCLUDED.comments,
+			joins = EXCLUDED.joins,
+			score = EXCLUDED.score,
+			time_updated = EXCLUDED.time_updated`,
+		since, messageWeight, commentWeight, joinWeight)
+	return err
+}
+
+// explainRecommendation lists why a club was recommended
+func explainRecommendation(club *schemas.ClubRecommendation) []string {
+	reasons := make([]string, 0)
+	switch {
+	case club.Overlap == 1:
+		reasons = append(reasons, "1 member you read with is here")
+	case club.Overlap > 1:
+		reasons = append(reasons, fmt.Sprintf("%d members you read with are here", club.Overlap))
+	}
+	if club.Joins > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d readers joined this week", club.Joins))
+	}
+	if club.Messages+club.Comments > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d messages and comments this week", club.Messages+club.Comments))
+	}
+	return reasons
+}
+
+// listRankedClubs gets a page of public clubs the user has not joined, ranked by
+// their trending score plus, when personal is set, their overlap with the user's clubs
+func listRankedClubs(userID string, n int, personal bool) ([]*schemas.ClubRecommendation, error) {
+	db := pgdb.GetDB()
+	pageSize := viper.GetInt("CLUB_PAGE_SIZE")
+	rank := "coalesce(cs.score, 0)"
+	if personal {
+		rank += fmt.Sprintf(" + %f * %s", overlapWeight, clubOverlap)
+	}
+	clubs := make([]*schemas.ClubRecommendation, 0)
+	err := db.Model((*models.Club)(nil)).
+		ColumnExpr(clubColumns).
+		ColumnExpr("coalesce(cs.messages, 0) AS messages").
+		ColumnExpr("coalesce(cs.comments, 0) AS comments").
+		ColumnExpr("coalesce(cs.joins, 0) AS joins").
+		ColumnExpr(clubOverlap+" AS overlap", userID).
+		ColumnExpr(rank+" AS score", userID).
+		Join("INNER JOIN users as u").
+		JoinOn("club.host_id = u.id").
+		Join("LEFT JOIN club_scores as cs").
+		JoinOn("cs.club_id = club.id").
+		Where("club.private = false").
+		Where("club.archived = false").
+		Where("NOT EXISTS (SELECT * FROM club_users cu WHERE cu.club_id = club.id AND cu.user_id = ?)", userID).
+		OrderExpr(rank+" DESC, club.id DESC", userID).
+		Offset((n - 1) * pageSize).
+		Limit(pageSize).
+		Select(&clubs)
+	if err != nil {
+		return nil, err
+	}
+	for _, club := range clubs {
+		club.Reasons = explainRecommendation(club)
+	}
+	return clubs, nil
+}
+
+// ListTrendingClubs gets a page of public clubs the user has not joined ordered by trending score
+func ListTrendingClubs(userID string, n int) ([]*schemas.ClubRecommendation, error) {
+	return listRankedClubs(userID, n, false)
+}
+
+// RecommendClubs gets a page of public clubs the user has not joined ordered by
+// trending score and the number of members they already read with
+func RecommendClubs(userID string, n int) ([]*schemas.ClubRecommendation, error) {
+	return listRankedClubs(userID, n, true)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/Krishap-s/keats-backend/models"
 	"github.com/Krishap-s/keats-backend/pgdb"
 	"github.com/Krishap-s/keats-backend/schemas"
+	"github.com/go-pg/pg/v10"
 	"github.com/google/uuid"
 )
 
@@ -24,12 +25,13 @@ func CreateComment(objIn *schemas.CommentCreate) (*models.Comment, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(objIn.Message) > 150 {
-		return nil, fmt.Errorf("max string length")
+	text, err := filterMessage(cid, uid, objIn.Message)
+	if err != nil {
+		return nil, err
 	}
 	comment := &models.Comment{
 		PageNo:   objIn.PageNo,
-		Message:  objIn.Message,
+		Message:  text,
 		ClubID:   cid,
 		UserID:   uid,
 		ParentID: pid,
@@ -42,30 +44,43 @@ func CreateComment(objIn *schemas.CommentCreate) (*models.Comment, error) {
 	return comment, nil
 }
 
-// GetComment gets chatmessages from a room or returns an error
-func GetComment(cid string) ([]*schemas.Comment, error) {
+// GetComment gets chatmessages from a room or returns an error. Comments by
+// users the viewer has blocked are flagged and their message is hidden so that
+// replies to them keep their place in the thread
+func GetComment(cid string, viewerID string) ([]*schemas.Comment, error) {
 	db := pgdb.GetDB()
 	var comments []*schemas.Comment
 	err := db.Model((*models.Comment)(nil)).
+		ColumnExpr("comment.*").
+		ColumnExpr("NOT "+fmt.Sprintf(notBlockedBy, "comment")+" AS blocked", viewerID).
 		Where("club_id = ?", cid).
 		Select(&comments)
 	if err != nil {
 		return nil, err
 	}
+	// Hidden and blocked comments keep their place so replies still have a parent
+	for _, comment := range comments {
+		if comment.Blocked || comment.Hidden {
+			comment.Message = ""
+		}
+	}
 	return comments, nil
 }
 
 // AddCommentLike increments likes field of chatmessage
 func AddCommentLike(id string) error {
 	db := pgdb.GetDB()
-	comment := models.Comment{}
-	_, err := db.Model(&comment).
+	// Updating through a nil model keeps the moderation hook from firing
+	res, err := db.Model((*models.Comment)(nil)).
 		Set("likes = likes + 1").
 		Where("id = ?", id).
-		Returning("id").
-		UpdateNotZero()
+		Where("NOT hidden").
+		Update()
 	if err != nil {
 		return err
 	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
 	return nil
 }
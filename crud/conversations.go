@@ -0,0 +1,257 @@
+package crud
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/contentfilter"
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// directMessagePageSize is the number of direct messages returned per page
+const directMessagePageSize = 50
+
+// eitherBlocked checks whether either of two users has blocked the other
+func eitherBlocked(userID string, otherID string) (bool, error) {
+	blocked, err := IsBlocked(userID, otherID)
+	if err != nil || blocked {
+		return blocked, err
+	}
+	return IsBlocked(otherID, userID)
+}
+
+// GetOrCreateConversation gets the conversation between two users, creating it if needed
+func GetOrCreateConversation(userID string, otherID string) (*models.Conversation, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	oid, err := uuid.Parse(otherID)
+	if err != nil {
+		return nil, err
+	}
+	if uid == oid {
+		return nil, errors.ErrSelfMessage
+	}
+	blocked, err := eitherBlocked(userID, otherID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, errors.ErrBlocked
+	}
+	conversation := &models.Conversation{
+		UserAID: uid,
+		UserBID: oid,
+	}
+	if uid.String() > oid.String() {
+		conversation.UserAID, conversation.UserBID = oid, uid
+	}
+	_, err = db.Model(conversation).
+		Where("user_a_id = ?user_a_id and user_b_id = ?user_b_id").
+		OnConflict("DO NOTHING").
+		Returning("*").
+		SelectOrInsert()
+	if err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// GetConversation gets a conversation the user takes part in or returns an error
+func GetConversation(id string, userID string) (*models.Conversation, error) {
+	db := pgdb.GetDB()
+	cid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.ErrConversationNotFound
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	conversation := &models.Conversation{
+		ID: cid,
+	}
+	err = db.Model(conversation).
+		WherePK().
+		Where("user_a_id = ?0 or user_b_id = ?0", uid).
+		Select()
+	if err != nil {
+		return nil, errors.ErrConversationNotFound
+	}
+	return conversation, nil
+}
+
+// ListConversation gets the conversations of a user, most recently active first
+func ListConversation(userID string) ([]*schemas.Conversation, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	conversations := make([]*schemas.Conversation, 0)
+	_, err = db.Query(&conversations, `SELECT c.id,
+		CASE WHEN c.user_a_id = ?0 THEN c.user_b_id ELSE c.user_a_id END AS user_id,
+		(SELECT count(*) FROM direct_messages dm
+			WHERE dm.conversation_id = c.id AND dm.recipient_id = ?0 AND dm.time_read IS NULL) AS unread
+		FROM conversations c
+		WHERE c.user_a_id = ?0 OR c.user_b_id = ?0
+		ORDER BY coalesce((SELECT max(dm.time_created) FROM direct_messages dm WHERE dm.conversation_id = c.id), c.time_created) DESC`,
+		uid)
+	if err != nil {
+		return nil, err
+	}
+	if len(conversations) == 0 {
+		return conversations, nil
+	}
+	userIDs := make([]string, 0, len(conversations))
+	conversationIDs := make([]string, 0, len(conversations))
+	for _, conversation := range conversations {
+		userIDs = append(userIDs, conversation.UserID)
+		conversationIDs = append(conversationIDs, conversation.ID)
+	}
+	users := make([]*schemas.PublicUser, 0)
+	err = db.Model((*models.User)(nil)).
+		ColumnExpr(publicUserColumns).
+		WhereIn("\"user\".\"id\" IN (?)", userIDs).
+		Select(&users)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[string]*schemas.PublicUser, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+	lastMessages := make([]*schemas.DirectMessage, 0)
+	err = db.Model((*models.DirectMessage)(nil)).
+		DistinctOn("conversation_id").
+		WhereIn("conversation_id IN (?)", conversationIDs).
+		Order("conversation_id", "time_created DESC", "id DESC").
+		Select(&lastMessages)
+	if err != nil {
+		return nil, err
+	}
+	lastMessageByConversation := make(map[string]*schemas.DirectMessage, len(lastMessages))
+	for _, message := range lastMessages {
+		lastMessageByConversation[message.ConversationID] = message
+	}
+	for _, conversation := range conversations {
+		conversation.User = usersByID[conversation.UserID]
+		if conversation.User == nil {
+			// The other user deleted their account, whose content is kept under the nil id
+			conversation.User = &schemas.PublicUser{ID: uuid.Nil.String()}
+		}
+		conversation.LastMessage = lastMessageByConversation[conversation.ID]
+	}
+	return conversations, nil
+}
+
+// CreateDirectMessage creates a direct message in the database or returns an error
+func CreateDirectMessage(objIn *schemas.DirectMessageCreate) (*models.DirectMessage, error) {
+	db := pgdb.GetDB()
+	conversation, err := GetConversation(objIn.ConversationID, objIn.SenderID)
+	if err != nil {
+		return nil, err
+	}
+	sid, err := uuid.Parse(objIn.SenderID)
+	if err != nil {
+		return nil, err
+	}
+	rid := conversation.UserAID
+	if rid == sid {
+		rid = conversation.UserBID
+	}
+	if _, err = GetUser(rid.String()); err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	blocked, err := eitherBlocked(sid.String(), rid.String())
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, errors.ErrBlocked
+	}
+	sender, err := GetUser(sid.String())
+	if err != nil {
+		return nil, err
+	}
+	text, err := filterDirectMessage(conversation.ID, sender, objIn.Message)
+	if err != nil {
+		return nil, err
+	}
+	message := &models.DirectMessage{
+		ConversationID: conversation.ID,
+		SenderID:       sid,
+		RecipientID:    rid,
+		Message:        text,
+		TimeCreated:    time.Now(),
+	}
+	_, err = db.Model(message).Returning("*").Insert()
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// filterDirectMessage runs the content filter pipeline on text sent in a
+// conversation, in the language the sender reads and at the standard
+// strictness of clubs, and returns the text to be persisted. Phone numbers
+// are only masked when CONTENT_FILTER_MASK_DIRECT_MESSAGES is set.
+func filterDirectMessage(conversationID uuid.UUID, sender *models.User, text string) (string, error) {
+	message := &contentfilter.Message{
+		ConversationID: conversationID.String(),
+		UserID:         sender.ID.String(),
+		Language:       sender.Locale,
+		Strictness:     contentfilter.Standard,
+		Text:           text,
+	}
+	if err := contentfilter.GetPipeline().Check(context.Background(), message); err != nil {
+		return "", err
+	}
+	return message.Text, nil
+}
+
+// GetDirectMessage gets a page of direct messages of a conversation, newest
+// first, sent before the message with id before if it is set
+func GetDirectMessage(conversationID string, before string) ([]*schemas.DirectMessage, error) {
+	db := pgdb.GetDB()
+	messages := make([]*schemas.DirectMessage, 0)
+	q := db.Model((*models.DirectMessage)(nil)).
+		Where("conversation_id = ?", conversationID)
+	if before != "" {
+		bid, err := uuid.Parse(before)
+		if err != nil {
+			return nil, errors.ErrInvalidCursor
+		}
+		q = q.Where("(time_created, id) < (SELECT time_created, id FROM direct_messages WHERE id = ?)", bid)
+	}
+	err := q.Order("time_created DESC", "id DESC").
+		Limit(directMessagePageSize).
+		Select(&messages)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkConversationRead marks every message the user received in a conversation as read
+func MarkConversationRead(conversationID string, userID string) (time.Time, error) {
+	db := pgdb.GetDB()
+	timeRead := time.Now()
+	_, err := db.Model((*models.DirectMessage)(nil)).
+		Set("time_read = ?", timeRead).
+		Where("conversation_id = ?", conversationID).
+		Where("recipient_id = ?", userID).
+		Where("time_read IS NULL").
+		Update()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return timeRead, nil
+}
@@ -0,0 +1,164 @@
+package crud
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/i18n"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+)
+
+// devicePlatforms are the platforms a device token may be registered for
+var devicePlatforms = map[string]bool{
+	"android": true,
+	"ios":     true,
+	"web":     true,
+}
+
+// RegisterDeviceToken registers a device of a user for push notifications,
+// moving the token over if it was registered by another user before
+func RegisterDeviceToken(userID string, token string, platform string) (*models.DeviceToken, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" || !devicePlatforms[platform] {
+		return nil, errors.ErrInvalidDeviceToken
+	}
+	device := &models.DeviceToken{
+		Token:       token,
+		UserID:      uid,
+		Platform:    platform,
+		TimeUpdated: time.Now(),
+	}
+	_, err = db.Model(device).
+		OnConflict("(token) DO UPDATE").
+		Set("user_id = EXCLUDED.user_id").
+		Set("platform = EXCLUDED.platform").
+		Set("time_updated = EXCLUDED.time_updated").
+		Returning("*").
+		Insert()
+	if err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// DeleteDeviceToken unregisters a device of a user from push notifications
+func DeleteDeviceToken(userID string, token string) error {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+	_, err = db.Model((*models.DeviceToken)(nil)).
+		Where("token = ?", token).
+		Where("user_id = ?", uid).
+		Delete()
+	return err
+}
+
+// deleteDeviceTokens forgets device tokens push providers no longer accept
+func deleteDeviceTokens(tokens []string) error {
+	db := pgdb.GetDB()
+	if len(tokens) == 0 {
+		return nil
+	}
+	_, err := db.Model((*models.DeviceToken)(nil)).
+		WhereIn("token IN (?)", tokens).
+		Delete()
+	return err
+}
+
+// listPushTokens gets the device tokens of users grouped by the locale their
+// users read in, leaving out users who muted the club if one is given
+func listPushTokens(userIDs []uuid.UUID, clubID uuid.UUID) (map[string][]string, error) {
+	db := pgdb.GetDB()
+	tokens := make(map[string][]string)
+	if len(userIDs) == 0 {
+		return tokens, nil
+	}
+	var rows []struct {
+		Token  string
+		Locale string
+	}
+	q := db.Model((*models.DeviceToken)(nil)).
+		ColumnExpr("device_token.token, u.locale").
+		Join("JOIN users u ON u.id = device_token.user_id").
+		WhereIn("device_token.user_id IN (?)", userIDs)
+	if clubID != uuid.Nil {
+		q = q.Where("NOT EXISTS (SELECT * FROM club_users cu WHERE cu.user_id = device_token.user_id AND cu.club_id = ? AND cu.muted)", clubID)
+	}
+	err := q.Select(&rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		locale := i18n.Negotiate(row.Locale, "")
+		tokens[locale] = append(tokens[locale], row.Token)
+	}
+	return tokens, nil
+}
+
+// ListClubPushTokens gets the device tokens of the members of a club who have
+// not muted it or turned chat pushes off and are outside of their quiet hours,
+// grouped by the locale their users read in. The senders of the messages
+// pushed are left out, and so are members who blocked any of them.
+func ListClubPushTokens(clubID string, senderIDs []string) (map[string][]string, error) {
+	db := pgdb.GetDB()
+	cid, err := uuid.Parse(clubID)
+	if err != nil {
+		return nil, err
+	}
+	senders := make([]uuid.UUID, 0, len(senderIDs))
+	for _, id := range senderIDs {
+		var uid uuid.UUID
+		uid, err = uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, uid)
+	}
+	var memberIDs []uuid.UUID
+	q := db.Model((*models.ClubUser)(nil)).
+		Column("user_id").
+		Where("club_id = ?", cid).
+		Where("NOT muted")
+	if len(senders) != 0 {
+		q = q.WhereIn("user_id NOT IN (?)", senders).
+			Where("NOT EXISTS (SELECT * FROM user_blocks ub WHERE ub.user_id = club_user.user_id AND ub.blocked_id IN (?))", pg.In(senders))
+	}
+	if err = q.Select(&memberIDs); err != nil {
+		return nil, err
+	}
+	_, pushRecipients, err := filterRecipients(memberIDs, cid, models.NotificationChat)
+	if err != nil {
+		return nil, err
+	}
+	return listPushTokens(pushRecipients, uuid.Nil)
+}
+
+// SetClubMute mutes or unmutes push notifications of a club for one of its members
+func SetClubMute(clubID string, userID string, muted bool) error {
+	db := pgdb.GetDB()
+	clubuser, err := parseClubUser(clubID, userID)
+	if err != nil {
+		return err
+	}
+	res, err := db.Model(clubuser).
+		Set("muted = ?", muted).
+		Where("user_id = ?user_id and club_id = ?club_id").
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return errors.ErrNotMember
+	}
+	return nil
+}
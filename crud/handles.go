@@ -0,0 +1,95 @@
+package crud
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// handlePattern matches handles of 3 to 20 lowercase letters, digits and
+// underscores starting with a letter
+var handlePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{2,19}$`)
+
+// reservedHandles cannot be taken by users
+var reservedHandles = map[string]bool{
+	"admin":     true,
+	"api":       true,
+	"everyone":  true,
+	"help":      true,
+	"here":      true,
+	"host":      true,
+	"keats":     true,
+	"moderator": true,
+	"root":      true,
+	"support":   true,
+	"system":    true,
+}
+
+// placeholderHandle generates the handle given to new users until they pick their own
+func placeholderHandle() string {
+	return "reader_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+// ValidateHandle checks that a lowercase handle is well formed and not reserved
+func ValidateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return errors.ErrInvalidHandle
+	}
+	// Placeholder handles handed out on sign up cannot be claimed
+	if reservedHandles[handle] || strings.HasPrefix(handle, "reader_") {
+		return errors.ErrReservedHandle
+	}
+	return nil
+}
+
+// IsHandleAvailable checks whether a handle is valid and not taken by another user
+func IsHandleAvailable(handle string) (bool, error) {
+	handle = strings.ToLower(handle)
+	if err := ValidateHandle(handle); err != nil {
+		return false, err
+	}
+	db := pgdb.GetDB()
+	exists, err := db.Model((*models.User)(nil)).
+		Where("handle = ?", handle).
+		Exists()
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
+
+// GetClubUserByHandle gets the members of a club with any of the given handles,
+// leaving out those who have blocked senderID
+func GetClubUserByHandle(clubID string, senderID string, handles []string) ([]*schemas.PublicUser, error) {
+	db := pgdb.GetDB()
+	cid, err := uuid.Parse(clubID)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*schemas.PublicUser, 0)
+	if len(handles) == 0 {
+		return users, nil
+	}
+	lowered := make([]string, len(handles))
+	for i, handle := range handles {
+		lowered[i] = strings.ToLower(handle)
+	}
+	err = db.Model((*models.User)(nil)).
+		ColumnExpr(publicUserColumns).
+		Join("INNER JOIN club_users as cu").
+		JoinOn("cu.user_id = \"user\".id").
+		Where("cu.club_id = ?", cid).
+		WhereIn("\"user\".\"handle\" IN (?)", lowered).
+		Where("NOT EXISTS (SELECT * FROM user_blocks ub WHERE ub.user_id = \"user\".id AND ub.blocked_id = ?)", senderID).
+		Select(&users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
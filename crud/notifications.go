@@ -0,0 +1,195 @@
+package crud
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+)
+
+// CreateNotification creates a notification in the database unless its user
+// has blocked its actor or turned its type off, and pushes it outside of quiet hours
+func CreateNotification(notification *models.Notification) error {
+	db := pgdb.GetDB()
+	if notification.ActorID == notification.UserID {
+		return nil
+	}
+	if notification.ActorID != uuid.Nil {
+		blocked, err := IsBlocked(notification.UserID.String(), notification.ActorID.String())
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return nil
+		}
+	}
+	recipients, pushRecipients, err := filterRecipients([]uuid.UUID{notification.UserID}, notification.ClubID, notification.Type)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	_, err = db.Model(notification).Returning("*").Insert()
+	if err != nil {
+		return err
+	}
+	pushNotification(pushRecipients, notification)
+	return nil
+}
+
+// createNotifications creates notifications in turn, stopping at the first
+// that fails
+func createNotifications(notifications []*models.Notification) error {
+	for _, notification := range notifications {
+		if err := CreateNotification(notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyUser creates a notification for a user from the string ids of its user, actor and club
+func NotifyUser(userID string, actorID string, clubID string, notificationType string, data map[string]interface{}) error {
+	notification := &models.Notification{
+		Type: notificationType,
+		Data: data,
+	}
+	var err error
+	if notification.UserID, err = uuid.Parse(userID); err != nil {
+		return err
+	}
+	if actorID != "" {
+		if notification.ActorID, err = uuid.Parse(actorID); err != nil {
+			return err
+		}
+	}
+	if clubID != "" {
+		if notification.ClubID, err = uuid.Parse(clubID); err != nil {
+			return err
+		}
+	}
+	return CreateNotification(notification)
+}
+
+// NotifyCommentReply notifies the author of the comment a reply was made to
+func NotifyCommentReply(reply *models.Comment) error {
+	db := pgdb.GetDB()
+	if reply.ParentID == uuid.Nil {
+		return nil
+	}
+	parent := &models.Comment{
+		ID: reply.ParentID,
+	}
+	if err := db.Model(parent).WherePK().Column("user_id").Select(); err != nil {
+		return err
+	}
+	return CreateNotification(&models.Notification{
+		UserID:  parent.UserID,
+		ActorID: reply.UserID,
+		ClubID:  reply.ClubID,
+		Type:    models.NotificationReply,
+		Data: map[string]interface{}{
+			"comment_id": reply.ID,
+			"parent_id":  reply.ParentID,
+			"page_no":    reply.PageNo,
+			"message":    reply.Message,
+		},
+	})
+}
+
+// NotifyClubMembers creates a notification for every member of a club except
+// its actor, members who have blocked the actor and members who turned its type off
+func NotifyClubMembers(clubID uuid.UUID, actorID uuid.UUID, notificationType string, data map[string]interface{}) error {
+	db := pgdb.GetDB()
+	var memberIDs []uuid.UUID
+	err := db.Model((*models.ClubUser)(nil)).
+		Column("user_id").
+		Where("club_id = ?", clubID).
+		Where("user_id <> ?", actorID).
+		Where("NOT EXISTS (SELECT * FROM user_blocks ub WHERE ub.user_id = club_user.user_id AND ub.blocked_id = ?)", actorID).
+		Select(&memberIDs)
+	if err != nil {
+		return err
+	}
+	recipients, pushRecipients, err := filterRecipients(memberIDs, clubID, notificationType)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	notifications := make([]*models.Notification, len(recipients))
+	for i, memberID := range recipients {
+		notifications[i] = &models.Notification{
+			UserID:  memberID,
+			ActorID: actorID,
+			ClubID:  clubID,
+			Type:    notificationType,
+			Data:    data,
+		}
+	}
+	_, err = db.Model(&notifications).Insert()
+	if err != nil {
+		return err
+	}
+	pushNotification(pushRecipients, notifications[0])
+	return nil
+}
+
+// ListNotification gets a page of the notifications of a user, newest first
+func ListNotification(userID string, n int) ([]*models.Notification, error) {
+	db := pgdb.GetDB()
+	pageSize := viper.GetInt("CLUB_PAGE_SIZE")
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make([]*models.Notification, 0)
+	err = db.Model(&notifications).
+		Where("user_id = ?", uid).
+		Order("time_created DESC").
+		Offset((n - 1) * pageSize).
+		Limit(pageSize).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// CountUnreadNotification counts the notifications a user has not read
+func CountUnreadNotification(userID string) (int, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, err
+	}
+	return db.Model((*models.Notification)(nil)).
+		Where("user_id = ?", uid).
+		Where("time_read IS NULL").
+		Count()
+}
+
+// MarkNotificationRead marks notifications of a user as read, all of them if ids is empty
+func MarkNotificationRead(userID string, ids []string) error {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+	q := db.Model((*models.Notification)(nil)).
+		Set("time_read = ?", time.Now()).
+		Where("user_id = ?", uid).
+		Where("time_read IS NULL")
+	if len(ids) != 0 {
+		parsed := make([]uuid.UUID, 0, len(ids))
+		for _, id := range ids {
+			nid, err := uuid.Parse(id)
+			if err != nil {
+				return err
+			}
+			parsed = append(parsed, nid)
+		}
+		q = q.WhereIn("id IN (?)", parsed)
+	}
+	_, err = q.Update()
+	return err
+}
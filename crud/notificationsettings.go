@@ -0,0 +1,218 @@
+package crud
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// notificationSettingColumns maps the types of notifications users can turn
+// off to the setting controlling them, other types are always delivered
+var notificationSettingColumns = map[string]string{
+	models.NotificationMention:    "mentions",
+	models.NotificationReply:      "replies",
+	models.NotificationChat:       "chat",
+	models.NotificationPageSync:   "page_sync",
+	models.NotificationClubUpdate: "club_updates",
+}
+
+// recipientSetting represents the effective preferences of a user for one type of notification in one club
+type recipientSetting struct {
+	UserID     uuid.UUID
+	Enabled    bool
+	QuietStart string
+	QuietEnd   string
+	Timezone   string
+}
+
+// inQuietHours reports whether t falls in the quiet hours of the recipient, in their timezone
+func (s *recipientSetting) inQuietHours(t time.Time) bool {
+	if s.QuietStart == "" || s.QuietEnd == "" {
+		return false
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start, err := time.Parse("15:04", s.QuietStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", s.QuietEnd)
+	if err != nil {
+		return false
+	}
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	// Quiet hours running past midnight
+	return now >= from || now < to
+}
+
+// listRecipientSettings gets the effective preferences of users for a type of notification in a club
+func listRecipientSettings(userIDs []uuid.UUID, clubID uuid.UUID, notificationType string) ([]*recipientSetting, error) {
+	db := pgdb.GetDB()
+	settings := make([]*recipientSetting, 0, len(userIDs))
+	if len(userIDs) == 0 {
+		return settings, nil
+	}
+	enabled := pg.Safe("true")
+	if column, ok := notificationSettingColumns[notificationType]; ok {
+		enabled = pg.Safe(fmt.Sprintf("COALESCE(c.%[1]s, g.%[1]s, true)", column))
+	}
+	_, err := db.Query(&settings, `
+		SELECT u.id AS user_id, ?0 AS enabled, g.quiet_start, g.quiet_end, g.timezone
+		FROM unnest(?1::uuid[]) AS u(id)
+		LEFT JOIN notification_settings AS g ON g.user_id = u.id AND g.club_id = ?2
+		LEFT JOIN notification_settings AS c ON c.user_id = u.id AND c.club_id = ?3 AND c.club_id <> ?2`,
+		enabled, pg.Array(userIDs), uuid.Nil, clubID)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// filterRecipients splits users into those who want a type of notification
+// from a club and those of them who may also be sent a push right now
+func filterRecipients(userIDs []uuid.UUID, clubID uuid.UUID, notificationType string) ([]uuid.UUID, []uuid.UUID, error) {
+	settings, err := listRecipientSettings(userIDs, clubID, notificationType)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	var recipients, pushRecipients []uuid.UUID
+	for _, setting := range settings {
+		if !setting.Enabled {
+			continue
+		}
+		recipients = append(recipients, setting.UserID)
+		if !setting.inQuietHours(now) {
+			pushRecipients = append(pushRecipients, setting.UserID)
+		}
+	}
+	return recipients, pushRecipients, nil
+}
+
+// NotificationEnabled reports whether a user wants a type of notification from a club
+func NotificationEnabled(userID string, clubID string, notificationType string) (bool, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return false, err
+	}
+	cid, err := uuid.Parse(clubID)
+	if err != nil {
+		return false, err
+	}
+	recipients, _, err := filterRecipients([]uuid.UUID{uid}, cid, notificationType)
+	if err != nil {
+		return false, err
+	}
+	return len(recipients) != 0, nil
+}
+
+// GetNotificationSetting gets the default preferences of a user along with their overrides for each club
+func GetNotificationSetting(userID string) (*schemas.NotificationSettings, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	var settings []*schemas.NotificationSetting
+	err = db.Model((*models.NotificationSetting)(nil)).
+		ColumnExpr("club_id, mentions, replies, chat, page_sync, club_updates, quiet_start, quiet_end, timezone").
+		Where("user_id = ?", uid).
+		Select(&settings)
+	if err != nil {
+		return nil, err
+	}
+	res := &schemas.NotificationSettings{
+		Default: &schemas.NotificationSetting{
+			ClubID: uuid.Nil.String(),
+		},
+		Clubs: make([]*schemas.NotificationSetting, 0),
+	}
+	for _, setting := range settings {
+		if setting.ClubID == uuid.Nil.String() {
+			res.Default = setting
+		} else {
+			res.Clubs = append(res.Clubs, setting)
+		}
+	}
+	return res, nil
+}
+
+// UpdateNotificationSetting updates the default preferences of a user, or
+// their overrides for a club when ClubID is set
+func UpdateNotificationSetting(userID string, objIn *schemas.NotificationSettingUpdate) (*models.NotificationSetting, error) {
+	db := pgdb.GetDB()
+	setting := &models.NotificationSetting{
+		Mentions:    objIn.Mentions,
+		Replies:     objIn.Replies,
+		Chat:        objIn.Chat,
+		PageSync:    objIn.PageSync,
+		ClubUpdates: objIn.ClubUpdates,
+	}
+	var err error
+	if setting.UserID, err = uuid.Parse(userID); err != nil {
+		return nil, err
+	}
+	if objIn.ClubID != "" {
+		if setting.ClubID, err = uuid.Parse(objIn.ClubID); err != nil {
+			return nil, err
+		}
+		var member bool
+		member, err = db.Model((*models.ClubUser)(nil)).
+			Where("club_id = ?", setting.ClubID).
+			Where("user_id = ?", setting.UserID).
+			Exists()
+		if err != nil {
+			return nil, err
+		}
+		if !member {
+			return nil, errors.ErrNotMember
+		}
+	} else {
+		if (objIn.QuietStart == "") != (objIn.QuietEnd == "") {
+			return nil, errors.ErrInvalidQuietHours
+		}
+		for _, t := range []string{objIn.QuietStart, objIn.QuietEnd} {
+			if _, err = time.Parse("15:04", t); t != "" && err != nil {
+				return nil, errors.ErrInvalidQuietHours
+			}
+		}
+		if _, err = time.LoadLocation(objIn.Timezone); err != nil {
+			return nil, errors.ErrInvalidTimezone
+		}
+		setting.QuietStart = objIn.QuietStart
+		setting.QuietEnd = objIn.QuietEnd
+		setting.Timezone = objIn.Timezone
+	}
+	// Every preference is replaced so sending null returns it to the default
+	_, err = db.Model(setting).
+		OnConflict("(user_id, club_id) DO UPDATE").
+		Set("mentions = EXCLUDED.mentions").
+		Set("replies = EXCLUDED.replies").
+		Set("chat = EXCLUDED.chat").
+		Set("page_sync = EXCLUDED.page_sync").
+		Set("club_updates = EXCLUDED.club_updates").
+		Set("quiet_start = EXCLUDED.quiet_start").
+		Set("quiet_end = EXCLUDED.quiet_end").
+		Set("timezone = EXCLUDED.timezone").
+		Returning("*").
+		Insert()
+	if err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
@@ -0,0 +1,72 @@
+package crud
+
+import (
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/i18n"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/push"
+)
+
+// notificationTitles are the push notification titles of each type of notification
+var notificationTitles = map[string]string{
+	models.NotificationReply:        "New reply to your comment",
+	models.NotificationMention:      "You were mentioned",
+	models.NotificationKick:         "You were removed from a club",
+	models.NotificationRoleChange:   "You are now the host of a club",
+	models.NotificationClubUpdate:   "A club you are in was updated",
+	models.NotificationWaitlistJoin: "A seat opened up in a club you were waiting for",
+	models.NotificationPageSync:     "Your club moved to a new page",
+}
+
+// NotificationTitle is the title of a type of notification in locale
+func NotificationTitle(locale string, notificationType string) string {
+	return i18n.Translate(locale, "notification."+notificationType, notificationTitles[notificationType], nil)
+}
+
+// SendPush sends a push notification to device tokens and forgets the tokens
+// that are no longer registered
+func SendPush(tokens []string, message *push.Message) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	unregistered, err := push.GetSender().Send(tokens, message)
+	if err != nil {
+		return err
+	}
+	return deleteDeviceTokens(unregistered)
+}
+
+// pushNotification sends a notification to the devices of the users it was
+// created for in the background
+func pushNotification(userIDs []uuid.UUID, notification *models.Notification) {
+	go func() {
+		tokens, err := listPushTokens(userIDs, notification.ClubID)
+		if err != nil {
+			log.Println("Push error:", err)
+			return
+		}
+		// Each locale gets its own message with the title translated
+		for locale, localeTokens := range tokens {
+			message := &push.Message{
+				Title:       NotificationTitle(locale, notification.Type),
+				CollapseKey: notification.Type + "_" + notification.ClubID.String(),
+				Data: map[string]string{
+					"type":    notification.Type,
+					"club_id": notification.ClubID.String(),
+				},
+			}
+			if clubname, ok := notification.Data["clubname"].(string); ok {
+				message.Body = clubname
+			}
+			if text, ok := notification.Data["message"].(string); ok {
+				message.Body = text
+			}
+			if err = SendPush(localeTokens, message); err != nil {
+				log.Println("Push error:", err)
+			}
+		}
+	}()
+}
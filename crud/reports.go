@@ -0,0 +1,360 @@
+package crud
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// reportTargetClub finds the club reported content belongs to, or the reported club itself
+func reportTargetClub(targetType string, targetID uuid.UUID) (uuid.UUID, error) {
+	db := pgdb.GetDB()
+	var clubID uuid.UUID
+	var err error
+	switch targetType {
+	case models.ReportChatMessage:
+		err = db.Model((*models.ChatMessage)(nil)).Column("club_id").Where("id = ?", targetID).Select(&clubID)
+	case models.ReportComment:
+		err = db.Model((*models.Comment)(nil)).Column("club_id").Where("id = ?", targetID).Select(&clubID)
+	case models.ReportClub:
+		err = db.Model((*models.Club)(nil)).Column("id").Where("id = ?", targetID).Select(&clubID)
+	case models.ReportUser:
+		var exists bool
+		exists, err = db.Model((*models.User)(nil)).Where("id = ?", targetID).Exists()
+		if err == nil && !exists {
+			err = pg.ErrNoRows
+		}
+	default:
+		return uuid.Nil, errors.ErrInvalidReport
+	}
+	if err == pg.ErrNoRows {
+		return uuid.Nil, errors.ErrReportTargetNotFound
+	}
+	return clubID, err
+}
+
+// CreateReport files a report from a user into the moderation queue, content
+// that collects REPORT_HIDE_THRESHOLD open reports is hidden until reviewed
+func CreateReport(reporterID string, objIn *schemas.ReportCreate) (*models.Report, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(reporterID)
+	if err != nil {
+		return nil, err
+	}
+	tid, err := uuid.Parse(objIn.TargetID)
+	if err != nil {
+		return nil, errors.ErrReportTargetNotFound
+	}
+	if objIn.TargetType == models.ReportUser && tid == uid {
+		return nil, errors.ErrSelfReport
+	}
+	clubID, err := reportTargetClub(objIn.TargetType, tid)
+	if err != nil {
+		return nil, err
+	}
+	// Content can only be reported by members who could see it
+	if objIn.TargetType == models.ReportChatMessage || objIn.TargetType == models.ReportComment {
+		var member bool
+		member, err = db.Model((*models.ClubUser)(nil)).
+			Where("club_id = ?", clubID).
+			Where("user_id = ?", uid).
+			Exists()
+		if err != nil {
+			return nil, err
+		}
+		if !member {
+			return nil, errors.ErrNotMember
+		}
+	}
+	report := &models.Report{
+		ReporterID: uid,
+		TargetType: objIn.TargetType,
+		TargetID:   tid,
+		ClubID:     clubID,
+		Reason:     objIn.Reason,
+		Status:     models.ReportOpen,
+	}
+	res, err := db.Model(report).OnConflict("DO NOTHING").Returning("*").Insert()
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected() == 0 {
+		return nil, errors.ErrAlreadyReported
+	}
+	threshold := viper.GetInt("REPORT_HIDE_THRESHOLD")
+	if threshold <= 0 {
+		threshold = 3
+	}
+	if objIn.TargetType == models.ReportChatMessage || objIn.TargetType == models.ReportComment {
+		var count int
+		count, err = db.Model((*models.Report)(nil)).
+			Where("target_type = ?", report.TargetType).
+			Where("target_id = ?", report.TargetID).
+			Where("status = ?", models.ReportOpen).
+			Count()
+		if err != nil {
+			return nil, err
+		}
+		if count >= threshold {
+			var content models.Hideable
+			content, err = SetContentHidden(db, report.TargetType, report.TargetID.String(), true)
+			if err != nil {
+				return nil, err
+			}
+			publishHidden(content)
+		}
+	}
+	return report, nil
+}
+
+// ListReport gets a page of the moderation queue filtered by status, oldest first
+func ListReport(status string, n int) ([]*models.Report, error) {
+	db := pgdb.GetDB()
+	pageSize := viper.GetInt("CLUB_PAGE_SIZE")
+	if status == "" {
+		status = models.ReportOpen
+	}
+	reports := make([]*models.Report, 0)
+	err := db.Model(&reports).
+		Where("status = ?", status).
+		Order("time_created ASC").
+		Offset((n - 1) * pageSize).
+		Limit(pageSize).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// SetContentHidden hides reported chat messages or comments from everyone, or
+// restores them. The updated content is returned for publishHidden to tell
+// clients once the change is committed.
+func SetContentHidden(db orm.DB, targetType string, targetID string, hidden bool) (models.Hideable, error) {
+	tid, err := uuid.Parse(targetID)
+	if err != nil {
+		return nil, errors.ErrReportTargetNotFound
+	}
+	var model models.Hideable
+	switch targetType {
+	case models.ReportChatMessage:
+		model = &models.ChatMessage{ID: tid, Hidden: hidden}
+	case models.ReportComment:
+		model = &models.Comment{ID: tid, Hidden: hidden}
+	default:
+		return nil, errors.ErrInvalidReport
+	}
+	_, err = db.Model(model).
+		Set("hidden = ?", hidden).
+		WherePK().
+		Returning("*").
+		Update()
+	if err == pg.ErrNoRows {
+		return nil, errors.ErrReportTargetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// contentHidden reads whether a chat message or comment is hidden, locking it
+// until the transaction ends
+func contentHidden(tx *pg.Tx, targetType string, targetID string) (bool, error) {
+	tid, err := uuid.Parse(targetID)
+	if err != nil {
+		return false, errors.ErrReportTargetNotFound
+	}
+	var model interface{}
+	switch targetType {
+	case models.ReportChatMessage:
+		model = (*models.ChatMessage)(nil)
+	case models.ReportComment:
+		model = (*models.Comment)(nil)
+	default:
+		return false, errors.ErrInvalidReport
+	}
+	var hidden bool
+	err = tx.Model(model).
+		Column("hidden").
+		Where("id = ?", tid).
+		For("UPDATE").
+		Select(pg.Scan(&hidden))
+	if err == pg.ErrNoRows {
+		return false, errors.ErrReportTargetNotFound
+	}
+	return hidden, err
+}
+
+// publishHidden tells clients to drop or restore content once hiding it is committed
+func publishHidden(content models.Hideable) {
+	if content == nil {
+		return
+	}
+	if err := content.PublishHidden(context.Background()); err != nil {
+		log.Println("Publish error:", err)
+	}
+}
+
+// SuspendUser bars a user from signing in until a given time and ends their
+// sessions, a nil time lifts the suspension. Their open websockets are closed
+// at the next frame they send. Only users whose role is below the one of the
+// actor can be suspended or have their suspension lifted.
+func SuspendUser(db orm.DB, actorID string, userID string, until *time.Time) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+	actor := new(models.User)
+	err = db.Model(actor).Column("role").Where("id = ?", actorID).Select()
+	if err != nil {
+		return err
+	}
+	target := new(models.User)
+	err = db.Model(target).Column("role").Where("id = ?", uid).Select()
+	if err == pg.ErrNoRows {
+		return errors.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if roleRanks[target.Role] >= roleRanks[actor.Role] {
+		return errors.ErrOutranked
+	}
+	res, err := db.Model((*models.User)(nil)).
+		Set("suspended_until = ?", until).
+		Set("token_version = token_version + 1").
+		Where("id = ?", uid).
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return errors.ErrUserNotFound
+	}
+	return nil
+}
+
+// ReviewReport settles a report and every other open report on the same
+// target, hiding the content or suspending its author when asked to
+func ReviewReport(reviewerID string, objIn *schemas.ReportReview, audit *models.AuditLog) (*models.Report, error) {
+	db := pgdb.GetDB()
+	rid, err := uuid.Parse(reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(objIn.ReportID)
+	if err != nil {
+		return nil, errors.ErrReportNotFound
+	}
+	report := &models.Report{
+		ID: id,
+	}
+	var content models.Hideable
+	err = db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		txErr := tx.Model(report).WherePK().For("UPDATE").Select()
+		if txErr == pg.ErrNoRows {
+			return errors.ErrReportNotFound
+		}
+		if txErr != nil {
+			return txErr
+		}
+		status := models.ReportResolved
+		switch objIn.Action {
+		case "dismiss":
+			status = models.ReportDismissed
+			// Content hidden by the report threshold comes back when reports are dismissed
+			if report.TargetType == models.ReportChatMessage || report.TargetType == models.ReportComment {
+				content, txErr = SetContentHidden(tx, report.TargetType, report.TargetID.String(), false)
+			}
+		case "hide":
+			content, txErr = SetContentHidden(tx, report.TargetType, report.TargetID.String(), true)
+		case "suspend":
+			var authorID uuid.UUID
+			authorID, txErr = reportTargetAuthor(tx, report)
+			if txErr != nil {
+				return txErr
+			}
+			// Suspensions without an end run until lifted
+			until := time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+			if objIn.SuspendDays > 0 {
+				until = time.Now().AddDate(0, 0, objIn.SuspendDays)
+			}
+			txErr = SuspendUser(tx, reviewerID, authorID.String(), &until)
+		case "resolve":
+		default:
+			return errors.ErrInvalidReportAction
+		}
+		if txErr != nil {
+			return txErr
+		}
+		now := time.Now()
+		_, txErr = tx.Model((*models.Report)(nil)).
+			Set("status = ?", status).
+			Set("reviewer_id = ?", rid).
+			Set("resolution = ?", objIn.Resolution).
+			Set("time_reviewed = ?", now).
+			Where("target_type = ?", report.TargetType).
+			Where("target_id = ?", report.TargetID).
+			Where("status = ? OR id = ?", models.ReportOpen, report.ID).
+			Update()
+		if txErr != nil {
+			return txErr
+		}
+		if audit != nil {
+			audit.TargetType = report.TargetType
+			audit.TargetID = report.TargetID
+			audit.ClubID = report.ClubID
+			audit.Before = map[string]interface{}{
+				"report_id": report.ID,
+				"status":    report.Status,
+			}
+			audit.After = map[string]interface{}{
+				"report_id":  report.ID,
+				"status":     status,
+				"action":     objIn.Action,
+				"resolution": objIn.Resolution,
+			}
+		}
+		report.Status = status
+		report.ReviewerID = rid
+		report.Resolution = objIn.Resolution
+		report.TimeReviewed = &now
+		return writeAuditLog(tx, audit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	publishHidden(content)
+	return report, nil
+}
+
+// reportTargetAuthor finds the user responsible for reported content
+func reportTargetAuthor(db orm.DB, report *models.Report) (uuid.UUID, error) {
+	var authorID uuid.UUID
+	var err error
+	switch report.TargetType {
+	case models.ReportUser:
+		return report.TargetID, nil
+	case models.ReportChatMessage:
+		err = db.Model((*models.ChatMessage)(nil)).Column("user_id").Where("id = ?", report.TargetID).Select(&authorID)
+	case models.ReportComment:
+		err = db.Model((*models.Comment)(nil)).Column("user_id").Where("id = ?", report.TargetID).Select(&authorID)
+	case models.ReportClub:
+		err = db.Model((*models.Club)(nil)).Column("host_id").Where("id = ?", report.TargetID).Select(&authorID)
+	}
+	if err == pg.ErrNoRows || (err == nil && authorID == uuid.Nil) {
+		return uuid.Nil, errors.ErrReportTargetNotFound
+	}
+	return authorID, err
+}
@@ -0,0 +1,112 @@
+package crud
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/pgdb"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+const maxClubTags = 10
+
+// clubHasTag filters clubs down to those labelled with a tag
+const clubHasTag = "EXISTS (SELECT * FROM club_tags ct INNER JOIN tags t ON t.id = ct.tag_id WHERE ct.club_id = club.id AND t.name = ?)"
+
+// tagClubCount counts the clubs labelled with a tag
+const tagClubCount = "(SELECT count(*) FROM club_tags ct WHERE ct.tag_id = tag.id) AS club_count"
+
+// NormalizeTag lowercases a tag and joins its words with hyphens
+func NormalizeTag(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+// normalizeTags normalizes and deduplicates tags or returns an error
+func normalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag := NormalizeTag(name)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > 30 {
+			return nil, errors.ErrMaxStringLength
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxClubTags {
+		return nil, errors.ErrTooManyTags
+	}
+	return tags, nil
+}
+
+// setClubTags replaces the tags of a club, creating tags that do not exist yet
+func setClubTags(db orm.DB, clubID uuid.UUID, names []string) error {
+	_, err := db.Model((*models.ClubTag)(nil)).Where("club_id = ?", clubID).Delete()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		tag := &models.Tag{
+			Name: name,
+		}
+		_, err = db.Model(tag).
+			Where("name = ?name").
+			OnConflict("(name) DO NOTHING").
+			Returning("*").
+			SelectOrInsert()
+		if err != nil {
+			return err
+		}
+		clubtag := &models.ClubTag{
+			ClubID: clubID,
+			TagID:  tag.ID,
+		}
+		_, err = db.Model(clubtag).OnConflict("DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListCategories gets the curated tags
+func ListCategories() ([]*schemas.Tag, error) {
+	db := pgdb.GetDB()
+	tags := make([]*schemas.Tag, 0)
+	err := db.Model((*models.Tag)(nil)).
+		ColumnExpr("tag.name,tag.curated").
+		ColumnExpr(tagClubCount).
+		Where("curated = true").
+		Order("name ASC").
+		Select(&tags)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// SearchTags gets the most used tags starting with prefix
+func SearchTags(prefix string) ([]*schemas.Tag, error) {
+	db := pgdb.GetDB()
+	prefix = NormalizeTag(prefix)
+	tags := make([]*schemas.Tag, 0)
+	err := db.Model((*models.Tag)(nil)).
+		ColumnExpr("tag.name,tag.curated").
+		ColumnExpr(tagClubCount).
+		Where("name LIKE ?", strings.NewReplacer("%", "\\%", "_", "\\_").Replace(prefix)+"%").
+		OrderExpr("club_count DESC, name ASC").
+		Limit(10).
+		Select(&tags)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
@@ -1,28 +1,38 @@
 package crud
 
 import (
-	"fmt"
+	"context"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/go-pg/pg/v10"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
 
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/i18n"
 	"github.com/Krishap-s/keats-backend/models"
 	"github.com/Krishap-s/keats-backend/pgdb"
 	"github.com/Krishap-s/keats-backend/schemas"
 )
 
+// publicUserColumns selects the columns of schemas.PublicUser from users
+const publicUserColumns = "\"user\".\"id\", \"user\".\"username\", \"user\".\"handle\", \"user\".\"profile_pic\", \"user\".\"bio\""
+
 // CreateUser creates a user in the database or returns an error
 func CreateUser(objIn *schemas.UserCreate) (*models.User, error) {
 	db := pgdb.GetDB()
+	// New users get a unique placeholder handle until they pick their own
+	handle := placeholderHandle()
 	if objIn.Username == "" {
-		objIn.Username = "Blake"
-	}
-	if len(objIn.Username) > 30 || len(objIn.Email) > 50 {
-		return nil, fmt.Errorf("max string length")
+		objIn.Username = handle
 	}
-
 	user := &models.User{
 		Username: objIn.Username,
+		Handle:   handle,
 		PhoneNo:  objIn.PhoneNo,
 	}
 
@@ -39,19 +49,27 @@ func UpdateUser(objIn *schemas.UserUpdate) (*models.User, error) {
 	db := pgdb.GetDB()
 
 	uid, err := uuid.Parse(objIn.ID)
-	if len(objIn.Username) > 30 || len(objIn.Email) > 50 || len(objIn.Bio) > 100 || len(objIn.ProfilePic) > 100 {
-		return nil, fmt.Errorf("max string length")
-	}
 	if err != nil {
 		return nil, err
 	}
+	if objIn.Handle != "" {
+		objIn.Handle = strings.ToLower(objIn.Handle)
+		if err = ValidateHandle(objIn.Handle); err != nil {
+			return nil, err
+		}
+	}
+	if objIn.Locale != "" && !i18n.IsSupported(objIn.Locale) {
+		return nil, errors.ErrInvalidLocale
+	}
 	user := &models.User{
 		ID:         uid,
+		Handle:     objIn.Handle,
 		PhoneNo:    objIn.PhoneNo,
 		ProfilePic: objIn.ProfilePic,
 		Username:   objIn.Username,
 		Email:      objIn.Email,
 		Bio:        objIn.Bio,
+		Locale:     objIn.Locale,
 	}
 
 	_, err = db.Model(user).Returning("*").WherePK().UpdateNotZero()
@@ -90,7 +108,7 @@ func GetUserClub(id string, n int) ([]*schemas.Club, error) {
 
 	var clubs []*schemas.Club
 	err = db.Model((*models.Club)(nil)).
-		ColumnExpr("club.id,club.club_name,club.club_pic,club.file_url,club.page_no,club.private,club.host_id,u.id as host_id,u.username as host_name,u.profile_pic as host_profile_pic").
+		ColumnExpr(clubColumns).
 		Join("INNER JOIN club_users as cu").
 		JoinOn("cu.club_id = club.id").
 		Join("INNER JOIN users as u").
@@ -104,3 +122,357 @@ func GetUserClub(id string, n int) ([]*schemas.Club, error) {
 	}
 	return clubs, nil
 }
+
+// GetPublicUser fetches the public details of an existing user or returns an error
+func GetPublicUser(id string) (*schemas.PublicUser, error) {
+	db := pgdb.GetDB()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	user := new(schemas.PublicUser)
+	err = db.Model((*models.User)(nil)).
+		ColumnExpr(publicUserColumns).
+		Where("\"user\".\"id\" = ?", uid).
+		Select(user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserStats counts the clubs and messages of a user
+func GetUserStats(id string) (*schemas.UserStats, error) {
+	db := pgdb.GetDB()
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	stats := new(schemas.UserStats)
+	_, err = db.QueryOne(stats, `SELECT
+		(SELECT count(*) FROM club_users WHERE user_id = ?0) AS clubs_joined,
+		(SELECT count(*) FROM clubs WHERE host_id = ?0) AS clubs_hosted,
+		(SELECT count(*) FROM chat_messages WHERE user_id = ?0) AS chat_messages,
+		(SELECT count(*) FROM comments WHERE user_id = ?0) AS comments`, uid)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetCommonClub gets the clubs both users are members of
+func GetCommonClub(id string, otherID string) ([]*schemas.Club, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	oid, err := uuid.Parse(otherID)
+	if err != nil {
+		return nil, err
+	}
+
+	clubs := make([]*schemas.Club, 0)
+	err = db.Model((*models.Club)(nil)).
+		ColumnExpr(clubColumns).
+		Join("INNER JOIN users as u").
+		JoinOn("club.host_id = u.id").
+		Where("EXISTS (SELECT * FROM club_users cu WHERE cu.club_id = club.id AND cu.user_id = ?)", uid).
+		Where("EXISTS (SELECT * FROM club_users cu WHERE cu.club_id = club.id AND cu.user_id = ?)", oid).
+		Select(&clubs)
+	if err != nil {
+		return nil, err
+	}
+	return clubs, nil
+}
+
+// ListUserMembership gets every club a user is a member of
+func ListUserMembership(id string) ([]*schemas.ClubMembership, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	memberships := make([]*schemas.ClubMembership, 0)
+	err = db.Model((*models.ClubUser)(nil)).
+		ColumnExpr("club_user.club_id, c.club_name, c.host_id = club_user.user_id AS host, club_user.time_created AS time_joined").
+		Join("INNER JOIN clubs as c").
+		JoinOn("c.id = club_user.club_id").
+		Where("club_user.user_id = ?", uid).
+		Order("time_joined ASC").
+		Select(&memberships)
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// ExportUser gathers a copy of the profile, memberships, chat messages and comments of a user
+func ExportUser(id string) (*schemas.UserExport, error) {
+	db := pgdb.GetDB()
+	user, err := GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	export := &schemas.UserExport{
+		User: schemas.User{
+			ID:            user.ID.String(),
+			Username:      user.Username,
+			Handle:        user.Handle,
+			PhoneNo:       user.PhoneNo,
+			ProfilePic:    user.ProfilePic,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+			Bio:           user.Bio,
+			Locale:        user.Locale,
+		},
+		ChatMessages:   make([]*schemas.ChatMessage, 0),
+		Comments:       make([]*schemas.Comment, 0),
+		DirectMessages: make([]*schemas.DirectMessage, 0),
+		TimeExported:   time.Now(),
+	}
+	export.Memberships, err = ListUserMembership(id)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Model((*models.ChatMessage)(nil)).
+		Where("user_id = ?", user.ID).
+		Order("time_created ASC").
+		Select(&export.ChatMessages)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Model((*models.Comment)(nil)).
+		Where("user_id = ?", user.ID).
+		Order("time_created ASC").
+		Select(&export.Comments)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Model((*models.DirectMessage)(nil)).
+		Where("sender_id = ?", user.ID).
+		Order("time_created ASC").
+		Select(&export.DirectMessages)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// DeleteUser removes a user from all of their clubs, handing hosted clubs over
+// through DeleteClubUser, then deletes or anonymizes their chat messages and
+// comments and deletes the user, all in one transaction
+func DeleteUser(id string, purge bool) (*models.User, error) {
+	db := pgdb.GetDB()
+	user, err := GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	var notifications []*models.Notification
+	err = db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		var clubIDs []string
+		txErr := tx.Model((*models.ClubUser)(nil)).
+			Column("club_id").
+			Where("user_id = ?", user.ID).
+			Select(&clubIDs)
+		if txErr != nil {
+			return txErr
+		}
+		for _, clubID := range clubIDs {
+			var removed []*models.Notification
+			_, removed, txErr = DeleteClubUser(tx, clubID, id, nil)
+			if txErr != nil {
+				return txErr
+			}
+			notifications = append(notifications, removed...)
+		}
+		content := []interface{}{
+			(*models.ChatMessage)(nil),
+			(*models.Comment)(nil),
+		}
+		for _, model := range content {
+			if purge {
+				_, txErr = tx.Model(model).Where("user_id = ?", user.ID).Delete()
+			} else {
+				_, txErr = tx.Model(model).Set("user_id = ?", uuid.Nil).Where("user_id = ?", user.ID).Update()
+			}
+			if txErr != nil {
+				return txErr
+			}
+		}
+		if purge {
+			_, txErr = tx.Model((*models.DirectMessage)(nil)).Where("sender_id = ?", user.ID).Delete()
+		} else {
+			_, txErr = tx.Model((*models.DirectMessage)(nil)).Set("sender_id = ?", uuid.Nil).Where("sender_id = ?", user.ID).Update()
+		}
+		if txErr != nil {
+			return txErr
+		}
+		// Messages received stay with their sender, and conversations with the
+		// other user who sees the deleted user under the nil id
+		_, txErr = tx.Model((*models.DirectMessage)(nil)).Set("recipient_id = ?", uuid.Nil).Where("recipient_id = ?", user.ID).Update()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.ClubWaitlist)(nil)).Where("user_id = ?", user.ID).Delete()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.UserBlock)(nil)).Where("user_id = ?0 or blocked_id = ?0", user.ID).Delete()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.Notification)(nil)).Where("user_id = ?", user.ID).Delete()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.DeviceToken)(nil)).Where("user_id = ?", user.ID).Delete()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.NotificationSetting)(nil)).Where("user_id = ?", user.ID).Delete()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.Report)(nil)).Where("reporter_id = ?", user.ID).Delete()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model((*models.PhoneNoHistory)(nil)).Where("user_id = ?", user.ID).Delete()
+		if txErr != nil {
+			return txErr
+		}
+		_, txErr = tx.Model(user).WherePK().Delete()
+		return txErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = createNotifications(notifications); err != nil {
+		log.Println("Notification error:", err)
+	}
+	return user, nil
+}
+
+// NormalizeEmail validates an email address and returns it trimmed and lowercased
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if utf8.RuneCountInString(email) > 50 {
+		return "", errors.ErrMaxStringLength
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", errors.ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// GetUserByEmail fetches the user a verified email belongs to or returns an error
+func GetUserByEmail(email string) (*models.User, error) {
+	db := pgdb.GetDB()
+	user := new(models.User)
+	err := db.Model(user).
+		Where("lower(email) = lower(?)", email).
+		Where("email_verified = true").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateEmailUser creates a user that signs in with a verified email or returns an error
+func CreateEmailUser(email string) (*models.User, error) {
+	db := pgdb.GetDB()
+	handle := placeholderHandle()
+	user := &models.User{
+		Username:      handle,
+		Handle:        handle,
+		Email:         email,
+		EmailVerified: true,
+	}
+	_, err := db.Model(user).Returning("*").Insert()
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// VerifyUserEmail sets the verified email of a user or returns an error
+func VerifyUserEmail(id string, email string) (*models.User, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{
+		ID: uid,
+	}
+	_, err = db.Model(user).
+		Set("email = ?", email).
+		Set("email_verified = true").
+		WherePK().
+		Returning("*").
+		Update()
+	if err != nil {
+		if pgErr, ok := err.(pg.Error); ok && pgErr.IntegrityViolation() {
+			return nil, errors.ErrEmailExists
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// IsPhoneNoTaken checks whether a phone number belongs to any user
+func IsPhoneNoTaken(phoneNo string) (bool, error) {
+	db := pgdb.GetDB()
+	return db.Model((*models.User)(nil)).
+		Where("phone_no = ?", phoneNo).
+		Exists()
+}
+
+// ChangeUserPhoneNo changes the phone number of a user, records the change and
+// invalidates existing sessions, returning the updated user and the old number
+func ChangeUserPhoneNo(id string, phoneNo string) (*models.User, string, error) {
+	db := pgdb.GetDB()
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, "", err
+	}
+	user := &models.User{
+		ID: uid,
+	}
+	var oldPhoneNo string
+	err = db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		txErr := tx.Model(user).WherePK().For("UPDATE").Select()
+		if txErr != nil {
+			return txErr
+		}
+		oldPhoneNo = user.PhoneNo
+		_, txErr = tx.Model(user).
+			Set("phone_no = ?", phoneNo).
+			Set("token_version = token_version + 1").
+			WherePK().
+			Returning("*").
+			Update()
+		if txErr != nil {
+			return txErr
+		}
+		history := &models.PhoneNoHistory{
+			UserID:     uid,
+			OldPhoneNo: oldPhoneNo,
+			NewPhoneNo: phoneNo,
+		}
+		_, txErr = tx.Model(history).Insert()
+		return txErr
+	})
+	if err != nil {
+		if pgErr, ok := err.(pg.Error); ok && pgErr.IntegrityViolation() {
+			return nil, "", errors.ErrPhoneNoExists
+		}
+		return nil, "", err
+	}
+	return user, oldPhoneNo, nil
+}
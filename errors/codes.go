@@ -0,0 +1,137 @@
+package errors
+
+import (
+	goerrors "errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Error is an error reported to clients under a stable, machine-readable code
+type Error struct {
+	// Code identifies the error to clients and never changes once published
+	Code string
+	// Status is the HTTP status the error is reported with
+	Status int
+	// Message describes the error to people
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// registry holds every error declared below in the order they were declared
+var registry []*Error
+
+func newError(code string, status int, message string) *Error {
+	err := &Error{
+		Code:    code,
+		Status:  status,
+		Message: message,
+	}
+	registry = append(registry, err)
+	return err
+}
+
+// All returns every error the API can report
+func All() []*Error {
+	return registry
+}
+
+// Is reports whether any error in err's chain matches target, as errors.Is of the standard library
+func Is(err, target error) bool {
+	return goerrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target, as errors.As of the standard library
+func As(err error, target interface{}) bool {
+	return goerrors.As(err, target)
+}
+
+// Requests
+var (
+	ErrMalformedJSON   = newError("malformed_json", fiber.StatusUnprocessableEntity, "JSON Data In Incorrect Format")
+	ErrMalformedForm   = newError("malformed_form", fiber.StatusUnprocessableEntity, "Form Data In Incorrect Format")
+	ErrMalformedQuery  = newError("malformed_query", fiber.StatusUnprocessableEntity, "Query Parameters In Incorrect Format")
+	ErrValidation      = newError("validation_failed", fiber.StatusUnprocessableEntity, "One or more fields are invalid")
+	ErrMaxStringLength = newError("max_string_length", fiber.StatusRequestEntityTooLarge, "One of your string inputs are too large")
+	ErrFileParse       = newError("file_parse_error", fiber.StatusBadRequest, "Error finding or parsing file")
+	ErrInvalidFileType = newError("invalid_file_type", fiber.StatusBadRequest, "Invalid file type")
+	ErrInvalidCursor   = newError("invalid_cursor", fiber.StatusBadRequest, "Invalid pagination cursor")
+	ErrUnknownAction   = newError("unknown_action", fiber.StatusBadRequest, "Action is not supported")
+	ErrTooManyRequests = newError("too_many_requests", fiber.StatusTooManyRequests, "Too Many Requests")
+	ErrInternal        = newError("internal_error", fiber.StatusInternalServerError, "Something went wrong")
+)
+
+// Authentication
+var (
+	ErrMalformedJWT        = newError("malformed_jwt", fiber.StatusBadRequest, "Missing or malformed JWT")
+	ErrInvalidJWT          = newError("invalid_jwt", fiber.StatusUnauthorized, "Invalid or Expired JWT")
+	ErrMalformedIDToken    = newError("malformed_id_token", fiber.StatusUnprocessableEntity, "Missing or Malformed IDToken")
+	ErrNoPhoneNo           = newError("id_token_missing_phone", fiber.StatusBadRequest, "IDToken missing phone_number")
+	ErrIDTokenVerification = newError("invalid_id_token", fiber.StatusUnauthorized, "IDToken verification failed or IDToken expired")
+	ErrInvalidEmailCode    = newError("invalid_email_code", fiber.StatusUnauthorized, "Invalid or expired code")
+	ErrMaxEmailCodes       = newError("max_email_codes", fiber.StatusTooManyRequests, "Too many codes requested, try again later")
+	ErrSuspended           = newError("suspended", fiber.StatusForbidden, "This account has been suspended")
+	ErrNotAdmin            = newError("not_admin", fiber.StatusForbidden, "You are not an administrator")
+)
+
+// Users
+var (
+	ErrUserNotFound        = newError("user_not_found", fiber.StatusNotFound, "User not found")
+	ErrPhoneNoExists       = newError("phone_number_exists", fiber.StatusConflict, "Phone Number already exists")
+	ErrPhoneChangeNotFound = newError("phone_change_not_found", fiber.StatusNotFound, "Phone number change not found or expired")
+	ErrSMSUnavailable      = newError("sms_unavailable", fiber.StatusServiceUnavailable, "Text messages cannot be sent, phone numbers cannot be changed right now")
+	ErrEmailExists         = newError("email_exists", fiber.StatusConflict, "Email already belongs to another account")
+	ErrInvalidEmail        = newError("invalid_email", fiber.StatusBadRequest, "Invalid email address")
+	ErrHandleExists        = newError("handle_exists", fiber.StatusConflict, "Handle is already taken")
+	ErrInvalidHandle       = newError("invalid_handle", fiber.StatusBadRequest, "Handles must be 3 to 20 letters, digits or underscores and start with a letter")
+	ErrReservedHandle      = newError("reserved_handle", fiber.StatusConflict, "Handle is reserved")
+	ErrSelfBlock           = newError("self_block", fiber.StatusConflict, "You cannot block yourself")
+	ErrInvalidRole         = newError("invalid_role", fiber.StatusUnprocessableEntity, "Role must be member, moderator or admin")
+	ErrSelfRole            = newError("self_role", fiber.StatusConflict, "You cannot change your own role")
+	ErrOutranked           = newError("outranked", fiber.StatusForbidden, "You can only suspend users whose role is below yours")
+	ErrInvalidDeviceToken  = newError("invalid_device_token", fiber.StatusUnprocessableEntity, "Device token or platform is invalid")
+	ErrInvalidQuietHours   = newError("invalid_quiet_hours", fiber.StatusUnprocessableEntity, "Quiet hours must be given as HH:MM")
+	ErrInvalidTimezone     = newError("invalid_timezone", fiber.StatusUnprocessableEntity, "Timezone is not a known IANA timezone")
+	ErrInvalidLocale       = newError("invalid_locale", fiber.StatusUnprocessableEntity, "Locale is not supported")
+)
+
+// Clubs
+var (
+	ErrClubNotFound        = newError("club_not_found", fiber.StatusNotFound, "Club not found")
+	ErrNoPublicClubs       = newError("no_public_clubs", fiber.StatusNotFound, "No public clubs found")
+	ErrNotMember           = newError("not_member", fiber.StatusUnauthorized, "You are not a member of this club")
+	ErrAlreadyMember       = newError("already_member", fiber.StatusConflict, "You are already a member of this club")
+	ErrNotHost             = newError("not_host", fiber.StatusUnauthorized, "You are not the host of this club")
+	ErrSelfKick            = newError("self_kick", fiber.StatusConflict, "You cannot kick yourself out of the club")
+	ErrMaxClubsCreated     = newError("max_clubs_created", fiber.StatusTooManyRequests, "You have exceeded maximum number of clubs created per user")
+	ErrClubFull            = newError("club_full", fiber.StatusConflict, "Club is full, you have been added to its waitlist")
+	ErrInvalidMaxMembers   = newError("invalid_max_members", fiber.StatusBadRequest, "max_members cannot be negative")
+	ErrClubArchived        = newError("club_archived", fiber.StatusConflict, "This club has been archived and is read-only")
+	ErrInvalidSort         = newError("invalid_sort", fiber.StatusBadRequest, "sort must be one of recent, activity or members")
+	ErrTooManyTags         = newError("too_many_tags", fiber.StatusBadRequest, "A club can have at most 10 tags")
+	ErrInvalidStrictness   = newError("invalid_strictness", fiber.StatusUnprocessableEntity, "Strictness must be relaxed, standard or strict")
+	ErrChatMessageNotFound = newError("chatmessage_not_found", fiber.StatusNotFound, "Chatmessage not found")
+	ErrCommentNotFound     = newError("comment_not_found", fiber.StatusNotFound, "Comment not found")
+)
+
+// Messages
+var (
+	ErrConversationNotFound = newError("conversation_not_found", fiber.StatusNotFound, "Conversation not found")
+	ErrSelfMessage          = newError("self_message", fiber.StatusConflict, "You cannot message yourself")
+	ErrBlocked              = newError("blocked", fiber.StatusForbidden, "You cannot message this user")
+	ErrMessageRejected      = newError("message_rejected", fiber.StatusUnprocessableEntity, "Message was rejected by the content filter")
+	ErrFlooding             = newError("flooding", fiber.StatusTooManyRequests, "You are sending messages too quickly")
+	ErrDuplicateMessage     = newError("duplicate_message", fiber.StatusConflict, "You have already sent this message")
+)
+
+// Moderation
+var (
+	ErrInvalidReport        = newError("invalid_report", fiber.StatusUnprocessableEntity, "Report type or reason is invalid")
+	ErrInvalidReportAction  = newError("invalid_report_action", fiber.StatusUnprocessableEntity, "Report action must be resolve, dismiss, hide or suspend")
+	ErrReportTargetNotFound = newError("report_target_not_found", fiber.StatusNotFound, "Reported content not found")
+	ErrReportNotFound       = newError("report_not_found", fiber.StatusNotFound, "Report not found")
+	ErrSelfReport           = newError("self_report", fiber.StatusConflict, "You cannot report yourself")
+	ErrAlreadyReported      = newError("already_reported", fiber.StatusConflict, "You have already reported this")
+)
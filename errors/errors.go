@@ -2,113 +2,83 @@ package errors
 
 import (
 	"log"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
-)
-
-func TooManyRequestsError(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-		"status":  "error",
-		"message": "Too Many Requests",
-	})
-}
-
-func BadRequestError(c *fiber.Ctx, err string) error {
-	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-		"status":  "error",
-		"message": err,
-	})
-}
+	"github.com/gofiber/fiber/v2/utils"
 
-func UnauthorizedError(c *fiber.Ctx, err string) error {
-	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-		"status":  "error",
-		"message": err,
-	})
-}
+	"github.com/Krishap-s/keats-backend/i18n"
+	"github.com/Krishap-s/keats-backend/validation"
+)
 
-func InternalServerError(c *fiber.Ctx, err string) error {
-	if err == "" {
-		err = "Something went wrong"
+// resolve finds the error to report to clients for err along with its field
+// details, errors the API does not know of are logged and reported as internal
+func resolve(err error) (*Error, validation.Errors) {
+	var fieldErrs validation.Errors
+	if As(err, &fieldErrs) {
+		return ErrValidation, fieldErrs
 	}
-	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-		"status":  "error",
-		"message": err,
-	})
-}
-func UnprocessableEntityError(c *fiber.Ctx, err string) error {
-	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-		"status":  "error",
-		"message": err,
-	})
+	var apiErr *Error
+	if As(err, &apiErr) {
+		return apiErr, nil
+	}
+	// Errors raised by fiber itself, such as unknown routes, keep their status
+	var fiberErr *fiber.Error
+	if As(err, &fiberErr) {
+		return &Error{
+			Code:    strings.ToLower(strings.ReplaceAll(utils.StatusMessage(fiberErr.Code), " ", "_")),
+			Status:  fiberErr.Code,
+			Message: fiberErr.Message,
+		}, nil
+	}
+	log.Println("Uncaught Error:", err.Error())
+	return ErrInternal, nil
 }
 
-func ConflictError(c *fiber.Ctx, err string) error {
-	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-		"status":  "error",
-		"message": err,
-	})
+// localize resolves err and translates its message and field details into locale
+func localize(err error, locale string) (*Error, string, validation.Errors) {
+	apiErr, fieldErrs := resolve(err)
+	message := i18n.Translate(locale, "error."+apiErr.Code, apiErr.Message, nil)
+	var details validation.Errors
+	for _, fieldErr := range fieldErrs {
+		translated := *fieldErr
+		translated.Message = i18n.Translate(locale, fieldErr.MessageKey(), fieldErr.Message, map[string]string{
+			"param": fieldErr.Param,
+		})
+		details = append(details, &translated)
+	}
+	return apiErr, message, details
 }
 
-func NotFoundError(c *fiber.Ctx, err string) error {
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-		"status":  "error",
-		"message": err,
-	})
+// Frame returns the websocket frame reporting err to the peer in locale
+func Frame(err error, locale string) fiber.Map {
+	apiErr, message, details := localize(err, locale)
+	return fiber.Map{
+		"action":  "error",
+		"code":    apiErr.Code,
+		"message": message,
+		"details": details,
+	}
 }
 
-func ConstraintError(c *fiber.Ctx, err string) error {
-	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
-		"status":  "error",
-		"message": err,
-	})
+func TooManyRequestsError(c *fiber.Ctx) error {
+	return ErrorHandler(c, ErrTooManyRequests)
 }
 
-func MaxCreated(c *fiber.Ctx, err string) error {
-	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-		"status":  "error",
-		"message": err,
-	})
-}
+// ErrorHandler reports an error returned by a handler as JSON with its code,
+// message, field details and the id of the request it failed. Messages are in
+// the locale the user chose or else the one their client asks for.
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	switch err.Error() {
-	case "form Data Incorrect":
-		return UnprocessableEntityError(c, "Form Data In Incorrect Format")
-	case "JSON Data Incorrect":
-		return UnprocessableEntityError(c, "JSON Data In Incorrect Format")
-	case "not member":
-		return UnauthorizedError(c, "You are not a member of this club")
-	case "already member":
-		return ConflictError(c, "You are already a member of this club")
-	case "club not found":
-		return NotFoundError(c, "Club not found")
-	case "not host":
-		return UnauthorizedError(c, "You are not the host of this club")
-	case "self kick":
-		return ConflictError(c, "You cannot kick yourself out of the club")
-	case "no public":
-		return NotFoundError(c, "No public clubs found")
-	case "malformed IDToken":
-		return UnprocessableEntityError(c, "Missing or Malformed IDToken")
-	case "no phoneNo":
-		return BadRequestError(c, "IDToken missing phone_number")
-	case "IDToken verification failed":
-		return UnauthorizedError(c, "IDToken verification failed or IDToken expired")
-	case "phoneNo exists":
-		return ConflictError(c, "Phone Number already exists")
-	case "file parse error":
-		return BadRequestError(c, "Error finding or parsing file")
-	case "invalid file type":
-		return BadRequestError(c, "Invalid file type")
-	case "malformed jwt":
-		return BadRequestError(c, "Missing or malformed JWT")
-	case "invalid jwt":
-		return UnauthorizedError(c, "Invalid or Expired JWT")
-	case "max string length":
-		return ConstraintError(c, "One of your string inputs are too large")
-	case "max clubs created":
-		return MaxCreated(c, "You have exceeded maximum number of clubs created per user")
-	}
-	log.Println("Uncaught Error:", err.Error())
-	return InternalServerError(c, "")
+	preferred, _ := c.Locals("locale").(string)
+	locale := i18n.Negotiate(preferred, c.Get(fiber.HeaderAcceptLanguage))
+	apiErr, message, details := localize(err, locale)
+	requestID, _ := c.Locals("requestid").(string)
+	c.Set(fiber.HeaderContentLanguage, locale)
+	return c.Status(apiErr.Status).JSON(fiber.Map{
+		"status":     "error",
+		"code":       apiErr.Code,
+		"message":    message,
+		"details":    details,
+		"request_id": requestID,
+	})
 }
@@ -6,18 +6,23 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
+	"firebase.google.com/go/v4/messaging"
 	"firebase.google.com/go/v4/storage"
 	"github.com/spf13/viper"
 	"google.golang.org/api/option"
+
+	"github.com/Krishap-s/keats-backend/errors"
 )
 
 var client *auth.Client = nil
 var bucket *storage.Client = nil
+var messenger *messaging.Client = nil
 
 func getApp() (*firebase.App, error) {
 	opt := option.WithCredentialsFile(viper.GetString("GOOGLE_APPLICATION_CREDENTIALS"))
@@ -58,12 +63,28 @@ func GetBucket() (*storage.Client, error) {
 	return bucket, nil
 }
 
+// GetMessaging returns a singleton reference to the cloud messaging client
+func GetMessaging() (*messaging.Client, error) {
+	if messenger != nil {
+		return messenger, nil
+	}
+	app, err := getApp()
+	if err != nil {
+		return nil, err
+	}
+	messenger, err = app.Messaging(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("firebase messaging client initialization failed: %v", err)
+	}
+	return messenger, nil
+}
+
 func WriteObject(file *multipart.File, acceptedType []string) (string, error) {
 	bucketName := viper.GetString("FIREBASE_BUCKET_NAME")
 	fileData := make([]byte, 512)
 	_, err := io.ReadAtLeast(*file, fileData, 512)
 	if err != nil {
-		return "", fmt.Errorf("file parse error")
+		return "", errors.ErrFileParse
 	}
 	// Resets file pointer
 	_, err = (*file).Seek(0, 0)
@@ -79,7 +100,7 @@ func WriteObject(file *multipart.File, acceptedType []string) (string, error) {
 		}
 	}
 	if !check {
-		return "", fmt.Errorf("invalid file type")
+		return "", errors.ErrInvalidFileType
 	}
 	bucketClient, err := GetBucket()
 	if err != nil {
@@ -102,3 +123,23 @@ func WriteObject(file *multipart.File, acceptedType []string) (string, error) {
 	fileURL := "https://firebasestorage.googleapis.com/v0/b/" + bucketName + "/o/public%2f" + fid + "?alt=media"
 	return fileURL, nil
 }
+
+// DeleteObject deletes a file previously stored by WriteObject, URLs that were
+// not written by WriteObject (such as default pictures) are left alone
+func DeleteObject(fileURL string) error {
+	bucketName := viper.GetString("FIREBASE_BUCKET_NAME")
+	prefix := "https://firebasestorage.googleapis.com/v0/b/" + bucketName + "/o/public%2f"
+	if !strings.HasPrefix(fileURL, prefix) {
+		return nil
+	}
+	fid := strings.TrimSuffix(strings.TrimPrefix(fileURL, prefix), "?alt=media")
+	bucketClient, err := GetBucket()
+	if err != nil {
+		return err
+	}
+	bucket, err := bucketClient.Bucket(bucketName)
+	if err != nil {
+		return err
+	}
+	return bucket.Object("public/" + fid).Delete(context.Background())
+}
@@ -7,7 +7,7 @@ require (
 	cloud.google.com/go/firestore v1.5.0 // indirect
 	cloud.google.com/go/storage v1.14.0 // indirect
 	firebase.google.com/go/v4 v4.4.0
-	github.com/fasthttp/websocket v1.4.3 // indirect
+	github.com/fasthttp/websocket v1.4.3
 	github.com/form3tech-oss/jwt-go v3.2.2+incompatible
 	github.com/go-pg/pg/v10 v10.9.1
 	github.com/go-redis/redis/v8 v8.8.2
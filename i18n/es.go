@@ -0,0 +1,93 @@
+package i18n
+
+// es holds the Spanish messages
+var es = map[string]string{
+	"error.malformed_json":          "Los datos JSON tienen un formato incorrecto",
+	"error.malformed_form":          "Los datos del formulario tienen un formato incorrecto",
+	"error.malformed_query":         "Los parámetros de la consulta tienen un formato incorrecto",
+	"error.validation_failed":       "Uno o más campos no son válidos",
+	"error.max_string_length":       "Uno de los textos enviados es demasiado largo",
+	"error.file_parse_error":        "No se pudo encontrar o leer el archivo",
+	"error.invalid_file_type":       "Tipo de archivo no válido",
+	"error.invalid_cursor":          "Cursor de paginación no válido",
+	"error.unknown_action":          "La acción no está disponible",
+	"error.too_many_requests":       "Demasiadas solicitudes",
+	"error.internal_error":          "Algo salió mal",
+	"error.invalid_locale":          "El idioma no está disponible",
+	"error.malformed_jwt":           "Falta el JWT o tiene un formato incorrecto",
+	"error.invalid_jwt":             "JWT no válido o caducado",
+	"error.malformed_id_token":      "Falta el IDToken o tiene un formato incorrecto",
+	"error.id_token_missing_phone":  "Al IDToken le falta phone_number",
+	"error.invalid_id_token":        "No se pudo verificar el IDToken o ha caducado",
+	"error.invalid_email_code":      "Código no válido o caducado",
+	"error.max_email_codes":         "Se han pedido demasiados códigos, inténtalo más tarde",
+	"error.suspended":               "Esta cuenta ha sido suspendida",
+	"error.not_admin":               "No eres administrador",
+	"error.user_not_found":          "Usuario no encontrado",
+	"error.phone_number_exists":     "El número de teléfono ya existe",
+	"error.phone_change_not_found":  "El cambio de número no existe o ha caducado",
+	"error.sms_unavailable":         "No se pueden enviar SMS, el número de teléfono no se puede cambiar ahora",
+	"error.email_exists":            "El correo ya pertenece a otra cuenta",
+	"error.invalid_email":           "Dirección de correo no válida",
+	"error.handle_exists":           "El nombre de usuario ya está en uso",
+	"error.invalid_handle":          "Los nombres de usuario deben tener de 3 a 20 letras, dígitos o guiones bajos y empezar por una letra",
+	"error.reserved_handle":         "El nombre de usuario está reservado",
+	"error.self_block":              "No puedes bloquearte a ti mismo",
+	"error.invalid_role":            "El rol debe ser member, moderator o admin",
+	"error.self_role":               "No puedes cambiar tu propio rol",
+	"error.outranked":               "Solo puedes suspender a usuarios con un rol inferior al tuyo",
+	"error.invalid_device_token":    "El token o la plataforma del dispositivo no son válidos",
+	"error.invalid_quiet_hours":     "Las horas de silencio deben indicarse como HH:MM",
+	"error.invalid_timezone":        "La zona horaria no es una zona IANA conocida",
+	"error.club_not_found":          "Club no encontrado",
+	"error.no_public_clubs":         "No se encontraron clubes públicos",
+	"error.not_member":              "No eres miembro de este club",
+	"error.already_member":          "Ya eres miembro de este club",
+	"error.not_host":                "No eres el anfitrión de este club",
+	"error.self_kick":               "No puedes expulsarte a ti mismo del club",
+	"error.max_clubs_created":       "Has superado el número máximo de clubes creados por usuario",
+	"error.club_full":               "El club está lleno, te hemos añadido a su lista de espera",
+	"error.invalid_max_members":     "max_members no puede ser negativo",
+	"error.club_archived":           "Este club ha sido archivado y es de solo lectura",
+	"error.invalid_sort":            "sort debe ser recent, activity o members",
+	"error.too_many_tags":           "Un club puede tener como máximo 10 etiquetas",
+	"error.invalid_strictness":      "El nivel de filtrado debe ser relaxed, standard o strict",
+	"error.chatmessage_not_found":   "Mensaje no encontrado",
+	"error.comment_not_found":       "Comentario no encontrado",
+	"error.conversation_not_found":  "Conversación no encontrada",
+	"error.self_message":            "No puedes enviarte mensajes a ti mismo",
+	"error.blocked":                 "No puedes enviar mensajes a este usuario",
+	"error.message_rejected":        "El filtro de contenido ha rechazado el mensaje",
+	"error.flooding":                "Estás enviando mensajes demasiado rápido",
+	"error.duplicate_message":       "Ya has enviado este mensaje",
+	"error.invalid_report":          "El tipo o el motivo de la denuncia no son válidos",
+	"error.invalid_report_action":   "La acción debe ser resolve, dismiss, hide o suspend",
+	"error.report_target_not_found": "No se encontró el contenido denunciado",
+	"error.report_not_found":        "Denuncia no encontrada",
+	"error.self_report":             "No puedes denunciarte a ti mismo",
+	"error.already_reported":        "Ya has denunciado esto",
+
+	"validation.required":       "es obligatorio",
+	"validation.min":            "debe ser al menos {param}",
+	"validation.min_characters": "debe tener al menos {param} caracteres",
+	"validation.min_items":      "debe tener al menos {param} elementos",
+	"validation.max":            "debe ser como máximo {param}",
+	"validation.max_characters": "debe tener como máximo {param} caracteres",
+	"validation.max_items":      "debe tener como máximo {param} elementos",
+	"validation.url":            "debe ser una URL http o https",
+	"validation.email":          "debe ser una dirección de correo",
+	"validation.e164":           "debe ser un número de teléfono en formato E.164",
+	"validation.uuid":           "debe ser un UUID",
+	"validation.oneof":          "debe ser uno de {param}",
+	"validation.clock":          "debe ser una hora del día como HH:MM",
+	"validation.timezone":       "debe ser una zona horaria IANA",
+
+	"notification.reply":         "Nueva respuesta a tu comentario",
+	"notification.mention":       "Te han mencionado",
+	"notification.kick":          "Te han sacado de un club",
+	"notification.role_change":   "Ahora eres el anfitrión de un club",
+	"notification.club_update":   "Un club en el que estás se ha actualizado",
+	"notification.waitlist_join": "Se ha liberado una plaza en un club que esperabas",
+	"notification.page_sync":     "Tu club ha pasado a otra página",
+	"notification.chat_batch":    "{count} mensajes nuevos",
+}
@@ -0,0 +1,93 @@
+package i18n
+
+// hi holds the Hindi messages
+var hi = map[string]string{
+	"error.malformed_json":          "JSON डेटा का प्रारूप गलत है",
+	"error.malformed_form":          "फ़ॉर्म डेटा का प्रारूप गलत है",
+	"error.malformed_query":         "क्वेरी पैरामीटर का प्रारूप गलत है",
+	"error.validation_failed":       "एक या अधिक फ़ील्ड अमान्य हैं",
+	"error.max_string_length":       "आपका कोई एक टेक्स्ट बहुत लंबा है",
+	"error.file_parse_error":        "फ़ाइल ढूँढने या पढ़ने में त्रुटि",
+	"error.invalid_file_type":       "अमान्य फ़ाइल प्रकार",
+	"error.invalid_cursor":          "अमान्य पेजिनेशन कर्सर",
+	"error.unknown_action":          "यह क्रिया उपलब्ध नहीं है",
+	"error.too_many_requests":       "बहुत अधिक अनुरोध",
+	"error.internal_error":          "कुछ गलत हो गया",
+	"error.invalid_locale":          "यह भाषा उपलब्ध नहीं है",
+	"error.malformed_jwt":           "JWT मौजूद नहीं है या उसका प्रारूप गलत है",
+	"error.invalid_jwt":             "JWT अमान्य है या उसकी अवधि समाप्त हो गई है",
+	"error.malformed_id_token":      "IDToken मौजूद नहीं है या उसका प्रारूप गलत है",
+	"error.id_token_missing_phone":  "IDToken में phone_number नहीं है",
+	"error.invalid_id_token":        "IDToken की पुष्टि नहीं हो सकी या उसकी अवधि समाप्त हो गई है",
+	"error.invalid_email_code":      "कोड अमान्य है या उसकी अवधि समाप्त हो गई है",
+	"error.max_email_codes":         "बहुत अधिक कोड माँगे गए, बाद में फिर प्रयास करें",
+	"error.suspended":               "यह खाता निलंबित कर दिया गया है",
+	"error.not_admin":               "आप व्यवस्थापक नहीं हैं",
+	"error.user_not_found":          "उपयोगकर्ता नहीं मिला",
+	"error.phone_number_exists":     "यह फ़ोन नंबर पहले से मौजूद है",
+	"error.phone_change_not_found":  "फ़ोन नंबर बदलाव नहीं मिला या उसकी अवधि समाप्त हो गई है",
+	"error.sms_unavailable":         "SMS नहीं भेजे जा सकते, अभी फ़ोन नंबर नहीं बदला जा सकता",
+	"error.email_exists":            "यह ईमेल किसी अन्य खाते से जुड़ा है",
+	"error.invalid_email":           "अमान्य ईमेल पता",
+	"error.handle_exists":           "यह हैंडल पहले से लिया जा चुका है",
+	"error.invalid_handle":          "हैंडल में 3 से 20 अक्षर, अंक या अंडरस्कोर होने चाहिए और वह किसी अक्षर से शुरू होना चाहिए",
+	"error.reserved_handle":         "यह हैंडल आरक्षित है",
+	"error.self_block":              "आप स्वयं को ब्लॉक नहीं कर सकते",
+	"error.invalid_role":            "भूमिका member, moderator या admin होनी चाहिए",
+	"error.self_role":               "आप अपनी भूमिका स्वयं नहीं बदल सकते",
+	"error.outranked":               "आप केवल अपने से निचली भूमिका वाले उपयोगकर्ताओं को निलंबित कर सकते हैं",
+	"error.invalid_device_token":    "डिवाइस टोकन या प्लैटफ़ॉर्म अमान्य है",
+	"error.invalid_quiet_hours":     "शांत समय HH:MM के रूप में दिया जाना चाहिए",
+	"error.invalid_timezone":        "यह कोई ज्ञात IANA समय क्षेत्र नहीं है",
+	"error.club_not_found":          "क्लब नहीं मिला",
+	"error.no_public_clubs":         "कोई सार्वजनिक क्लब नहीं मिला",
+	"error.not_member":              "आप इस क्लब के सदस्य नहीं हैं",
+	"error.already_member":          "आप पहले से इस क्लब के सदस्य हैं",
+	"error.not_host":                "आप इस क्लब के होस्ट नहीं हैं",
+	"error.self_kick":               "आप स्वयं को क्लब से नहीं निकाल सकते",
+	"error.max_clubs_created":       "आपने प्रति उपयोगकर्ता बनाए जा सकने वाले क्लबों की सीमा पार कर ली है",
+	"error.club_full":               "क्लब भरा हुआ है, आपको प्रतीक्षा सूची में जोड़ दिया गया है",
+	"error.invalid_max_members":     "max_members ऋणात्मक नहीं हो सकता",
+	"error.club_archived":           "यह क्लब संग्रहीत है और केवल पढ़ा जा सकता है",
+	"error.invalid_sort":            "sort का मान recent, activity या members होना चाहिए",
+	"error.too_many_tags":           "किसी क्लब में अधिकतम 10 टैग हो सकते हैं",
+	"error.invalid_strictness":      "फ़िल्टर स्तर relaxed, standard या strict होना चाहिए",
+	"error.chatmessage_not_found":   "संदेश नहीं मिला",
+	"error.comment_not_found":       "टिप्पणी नहीं मिली",
+	"error.conversation_not_found":  "बातचीत नहीं मिली",
+	"error.self_message":            "आप स्वयं को संदेश नहीं भेज सकते",
+	"error.blocked":                 "आप इस उपयोगकर्ता को संदेश नहीं भेज सकते",
+	"error.message_rejected":        "सामग्री फ़िल्टर ने संदेश अस्वीकार कर दिया",
+	"error.flooding":                "आप बहुत तेज़ी से संदेश भेज रहे हैं",
+	"error.duplicate_message":       "आप यह संदेश पहले ही भेज चुके हैं",
+	"error.invalid_report":          "रिपोर्ट का प्रकार या कारण अमान्य है",
+	"error.invalid_report_action":   "क्रिया resolve, dismiss, hide या suspend होनी चाहिए",
+	"error.report_target_not_found": "रिपोर्ट की गई सामग्री नहीं मिली",
+	"error.report_not_found":        "रिपोर्ट नहीं मिली",
+	"error.self_report":             "आप स्वयं की रिपोर्ट नहीं कर सकते",
+	"error.already_reported":        "आप इसकी रिपोर्ट पहले ही कर चुके हैं",
+
+	"validation.required":       "आवश्यक है",
+	"validation.min":            "कम से कम {param} होना चाहिए",
+	"validation.min_characters": "में कम से कम {param} अक्षर होने चाहिए",
+	"validation.min_items":      "में कम से कम {param} आइटम होने चाहिए",
+	"validation.max":            "अधिकतम {param} होना चाहिए",
+	"validation.max_characters": "में अधिकतम {param} अक्षर हो सकते हैं",
+	"validation.max_items":      "में अधिकतम {param} आइटम हो सकते हैं",
+	"validation.url":            "http या https URL होना चाहिए",
+	"validation.email":          "ईमेल पता होना चाहिए",
+	"validation.e164":           "E.164 प्रारूप में फ़ोन नंबर होना चाहिए",
+	"validation.uuid":           "UUID होना चाहिए",
+	"validation.oneof":          "इनमें से एक होना चाहिए: {param}",
+	"validation.clock":          "HH:MM के रूप में समय होना चाहिए",
+	"validation.timezone":       "IANA समय क्षेत्र होना चाहिए",
+
+	"notification.reply":         "आपकी टिप्पणी पर नया जवाब",
+	"notification.mention":       "आपका उल्लेख किया गया",
+	"notification.kick":          "आपको एक क्लब से हटा दिया गया",
+	"notification.role_change":   "अब आप एक क्लब के होस्ट हैं",
+	"notification.club_update":   "आपके एक क्लब में बदलाव हुआ है",
+	"notification.waitlist_join": "जिस क्लब की आप प्रतीक्षा कर रहे थे उसमें जगह खाली हुई है",
+	"notification.page_sync":     "आपका क्लब अगले पृष्ठ पर पहुँच गया है",
+	"notification.chat_batch":    "{count} नए संदेश",
+}
@@ -0,0 +1,88 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the locale messages are written in throughout the code,
+// used when neither the user nor their client asks for a supported locale
+const DefaultLocale = "en"
+
+// catalogs holds the translated messages of each supported locale by message
+// key. Keys are "error.<code>" for API errors, "validation.<rule>" for field
+// errors and "notification.<type>" for notifications. Placeholders such as
+// {param} are filled in by Translate.
+var catalogs = map[string]map[string]string{
+	DefaultLocale: {},
+	"es":          es,
+	"hi":          hi,
+}
+
+// IsSupported reports whether messages can be given in locale
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Locales returns every supported locale
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Negotiate picks the locale to answer in, the one a user chose for
+// themselves comes first and the most preferred supported language of an
+// Accept-Language header after that
+func Negotiate(preferred string, acceptLanguage string) string {
+	if IsSupported(preferred) {
+		return preferred
+	}
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var candidates []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		// Regional variants fall back to their language, hi-IN is served as hi
+		locale := strings.ToLower(strings.SplitN(fields[0], "-", 2)[0])
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		if q > 0 && IsSupported(locale) {
+			candidates = append(candidates, weighted{locale, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLocale
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].locale
+}
+
+// Translate returns the message under key in locale, or fallback when the
+// locale has no such message, with each {name} replaced by params[name]
+func Translate(locale string, key string, fallback string, params map[string]string) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		message = fallback
+	}
+	for name, value := range params {
+		message = strings.ReplaceAll(message, "{"+name+"}", value)
+	}
+	return message
+}
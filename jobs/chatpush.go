@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/i18n"
+	"github.com/Krishap-s/keats-backend/push"
+)
+
+// chatPushBatch collects the chat messages sent in a club during one batching window
+type chatPushBatch struct {
+	count       int
+	lastMessage string
+	senders     map[string]bool
+}
+
+var (
+	chatPushMu      sync.Mutex
+	chatPushBatches = make(map[string]*chatPushBatch)
+)
+
+// QueueChatPush adds a chat message to the pending push of its club, the
+// first message of a window schedules a single push for every message sent
+// to the club until CHAT_PUSH_WINDOW_IN_SECONDS have passed
+func QueueChatPush(clubID string, senderID string, message string) {
+	chatPushMu.Lock()
+	defer chatPushMu.Unlock()
+	batch, ok := chatPushBatches[clubID]
+	if !ok {
+		batch = &chatPushBatch{
+			senders: make(map[string]bool),
+		}
+		chatPushBatches[clubID] = batch
+		window := time.Duration(viper.GetInt("CHAT_PUSH_WINDOW_IN_SECONDS")) * time.Second
+		if window <= 0 {
+			window = 30 * time.Second
+		}
+		time.AfterFunc(window, func() {
+			flushChatPush(clubID)
+		})
+	}
+	batch.count++
+	batch.lastMessage = message
+	batch.senders[senderID] = true
+}
+
+// flushChatPush sends the pending push of a club to its members who did not
+// take part in the conversation, collapsing it with earlier chat pushes
+func flushChatPush(clubID string) {
+	chatPushMu.Lock()
+	batch := chatPushBatches[clubID]
+	delete(chatPushBatches, clubID)
+	chatPushMu.Unlock()
+	if batch == nil {
+		return
+	}
+	club, err := crud.GetClub(clubID)
+	if err != nil {
+		log.Println("Push error:", err)
+		return
+	}
+	senders := make([]string, 0, len(batch.senders))
+	for sender := range batch.senders {
+		senders = append(senders, sender)
+	}
+	tokens, err := crud.ListClubPushTokens(clubID, senders)
+	if err != nil {
+		log.Println("Push error:", err)
+		return
+	}
+	for locale, localeTokens := range tokens {
+		body := batch.lastMessage
+		if batch.count > 1 {
+			body = i18n.Translate(locale, "notification.chat_batch", "{count} new messages", map[string]string{
+				"count": strconv.Itoa(batch.count),
+			})
+		}
+		err = crud.SendPush(localeTokens, &push.Message{
+			Title:       club.ClubName,
+			Body:        body,
+			CollapseKey: "chat_" + clubID,
+			Data: map[string]string{
+				"type":    "chatmessage",
+				"club_id": clubID,
+			},
+		})
+		if err != nil {
+			log.Println("Push error:", err)
+		}
+	}
+}
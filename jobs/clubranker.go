@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/crud"
+)
+
+// rankingWindow is how far back activity counts towards the trending score of a club
+const rankingWindow = 7 * 24 * time.Hour
+
+// rankClubs recomputes the trending scores of all clubs
+func rankClubs() {
+	if err := crud.RankClubs(time.Now().Add(-rankingWindow)); err != nil {
+		log.Println("Ranker error:", err)
+	}
+}
+
+// StartClubRanker recomputes trending scores now and then periodically
+func StartClubRanker() {
+	interval := time.Duration(viper.GetInt("CLUB_RANKING_INTERVAL_IN_MINUTES")) * time.Minute
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		rankClubs()
+		for range ticker.C {
+			rankClubs()
+		}
+	}()
+}
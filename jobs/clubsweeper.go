@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/firebaseclient"
+	"github.com/Krishap-s/keats-backend/models"
+)
+
+// sweepAbandonedClubs deletes every club that has no host and no members left
+func sweepAbandonedClubs() {
+	clubs, err := crud.ListAbandonedClubs()
+	if err != nil {
+		log.Println("Sweeper error:", err)
+		return
+	}
+	for _, club := range clubs {
+		deleted, err := crud.DeleteClub(club.ID.String(), &models.AuditLog{
+			Action: "sweep_club",
+		})
+		if err != nil {
+			log.Println("Sweeper error:", err)
+			continue
+		}
+		for _, fileURL := range []string{deleted.ClubPic, deleted.FileURL} {
+			if err = firebaseclient.DeleteObject(fileURL); err != nil {
+				log.Println("Sweeper error:", err)
+			}
+		}
+	}
+}
+
+// StartClubSweeper periodically removes clubs abandoned by all of their members
+func StartClubSweeper() {
+	interval := time.Duration(viper.GetInt("CLUB_SWEEP_INTERVAL_IN_MINUTES")) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			sweepAbandonedClubs()
+		}
+	}()
+}
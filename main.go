@@ -5,26 +5,15 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/gofiber/fiber/v2/middleware/cors"
-
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
-	jwtware "github.com/gofiber/jwt/v2"
 	"github.com/spf13/viper"
 
-	"github.com/Krishap-s/keats-backend/api/endpoints/clubs"
-	"github.com/Krishap-s/keats-backend/api/endpoints/sockets"
-	"github.com/Krishap-s/keats-backend/api/endpoints/users"
-	"github.com/Krishap-s/keats-backend/configs"
+	"github.com/Krishap-s/keats-backend/api/server"
+	"github.com/Krishap-s/keats-backend/api/versions"
+	"github.com/Krishap-s/keats-backend/jobs"
+	"github.com/Krishap-s/keats-backend/openapi"
 	"github.com/Krishap-s/keats-backend/pgdb"
 )
 
-func healthCheck(c *fiber.Ctx) error {
-	return c.SendString("OK")
-}
-
 func main() {
 	// Set global configuration
 	viper.SetConfigName(".env")
@@ -34,18 +23,7 @@ func main() {
 		log.Panicln(fmt.Errorf("fatal error config file: %s", err))
 	}
 
-	app := fiber.New(configs.FiberConfig())
-
-	// Use Middleware
-	app.Use(limiter.New(configs.LimiterConfig()))
-	app.Use(logger.New(configs.LoggerConfig()))
-	app.Use(recover.New(configs.RecoverConfig()))
-	app.Use(cors.New(configs.CORSConfig()))
-
-	// Setting up jwt config
-	jwtconf := configs.JWTConfig()
-
-	app.Get("/", healthCheck)
+	app := server.New()
 
 	// Run pgdb migrations
 	log.Println("Running database migrations")
@@ -53,9 +31,11 @@ func main() {
 		log.Panic(err)
 	}
 
-	users.MountRoutes(app, jwtware.New(jwtconf))
-	clubs.MountRoutes(app, jwtware.New(jwtconf))
-	sockets.MountWebsockets(app, jwtware.New(jwtconf))
+	// Start background jobs
+	jobs.StartClubSweeper()
+	jobs.StartClubRanker()
+
+	openapi.CheckRoutes(app, versions.Prefixes()...)
 
 	if err := app.Listen("0.0.0.0:" + viper.GetString("PORT")); err != nil {
 		log.Panic(err)
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog represents an administrative action recorded in the database
+type AuditLog struct {
+	ID          uuid.UUID              `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ActorID     uuid.UUID              `pg:"type:uuid,nopk" json:"actor_id"`
+	Action      string                 `pg:",notnull" json:"action"`
+	TargetType  string                 `json:"target_type"`
+	TargetID    uuid.UUID              `pg:"type:uuid,nopk" json:"target_id"`
+	ClubID      uuid.UUID              `pg:"type:uuid,nopk" json:"club_id"`
+	Before      map[string]interface{} `pg:"type:jsonb" json:"before"`
+	After       map[string]interface{} `pg:"type:jsonb" json:"after"`
+	RequestID   string                 `json:"request_id"`
+	TimeCreated time.Time              `pg:",notnull,default:now()" json:"time_created"`
+}
@@ -1,8 +1,13 @@
 package models
 
 import (
+	"context"
+	"encoding/json"
+	"log"
 	"time"
 
+	"github.com/Krishap-s/keats-backend/redisclient"
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
@@ -14,4 +19,27 @@ type ChatMessage struct {
 	Message     string    `pg:",notnull" json:"message"`
 	Likes       int       `pg:",notnull,default:0" json:"likes"`
 	TimeCreated time.Time `pg:",notnull,default:now()" json:"time_created"`
+	Hidden      bool      `pg:",notnull,use_zero,default:false" json:"-"`
+}
+
+var _ Hideable = (*ChatMessage)(nil)
+
+// PublishHidden publishes to websocket clients that the chatmessage was hidden or restored by a moderator
+func (m *ChatMessage) PublishHidden(ctx context.Context) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	var byteData []byte
+	byteData, err = json.Marshal(fiber.Map{
+		"action":         "chatmessage_hidden",
+		"chatmessage_id": m.ID,
+		"hidden":         m.Hidden,
+	})
+	if err != nil {
+		log.Println("Publish error:", err)
+		return nil
+	}
+	rdb.Publish(ctx, m.ClubID.String(), byteData)
+	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/Krishap-s/keats-backend/redisclient"
 	"github.com/go-pg/pg/v10"
@@ -13,14 +14,23 @@ import (
 
 // Room represents a room in the database
 type Club struct {
-	ClubName string    `pg:",notnull" json:"clubname"`
-	ClubPic  string    `pg:",default:'https://firebasestorage.googleapis.com/v0/b/keats-caa65.appspot.com/o/public%2Fdefault_club_pic.png?alt=media'" json:"club_pic"`
-	FileURL  string    `pg:",notnull" json:"file_url"`
-	PageNo   int       `json:"page_no"`
-	ID       uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	HostID   uuid.UUID `pg:",type:uuid" json:"host_id"`
-	PageSync bool      `pg:",use_zero" json:"page_sync"`
-	Private  bool      `pg:",use_zero" json:"private"`
+	ClubName    string    `pg:",notnull" json:"clubname"`
+	ClubPic     string    `pg:",default:'https://firebasestorage.googleapis.com/v0/b/keats-caa65.appspot.com/o/public%2Fdefault_club_pic.png?alt=media'" json:"club_pic"`
+	FileURL     string    `pg:",notnull" json:"file_url"`
+	PageNo      int       `json:"page_no"`
+	ID          uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	HostID      uuid.UUID `pg:",type:uuid" json:"host_id"`
+	PageSync    bool      `pg:",use_zero" json:"page_sync"`
+	Private     bool      `pg:",use_zero" json:"private"`
+	MaxMembers  int       `pg:",use_zero,notnull,default:0" json:"max_members"`
+	Archived    bool      `pg:",use_zero" json:"archived"`
+	BookTitle   string    `json:"book_title"`
+	BookAuthor  string    `json:"book_author"`
+	Genre       string    `json:"genre"`
+	Language    string    `json:"language"`
+	TimeCreated time.Time `pg:",notnull,default:now()" json:"time_created"`
+	// FilterStrictness is how strictly chat messages and comments are filtered
+	FilterStrictness string `pg:",notnull,default:'standard'" json:"filter_strictness"`
 }
 
 var _ pg.AfterUpdateHook = (*Club)(nil)
@@ -44,3 +54,23 @@ func (c *Club) AfterUpdate(ctx context.Context) error {
 	rdb.Publish(ctx, clubID, byteData)
 	return nil
 }
+
+// PublishDeleted publishes to websocket clients that the club has been deleted
+func (c *Club) PublishDeleted(ctx context.Context) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	clubID := c.ID.String()
+	var byteData []byte
+	byteData, err = json.Marshal(fiber.Map{
+		"action": "club_deleted",
+		"data":   clubID,
+	})
+	if err != nil {
+		log.Println("Publish error:", err)
+		return nil
+	}
+	rdb.Publish(ctx, clubID, byteData)
+	return nil
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClubScore represents the trending score of a club computed by the ranking job
+type ClubScore struct {
+	ClubID      uuid.UUID `pg:",pk,type:uuid" json:"club_id"`
+	Messages    int       `pg:",use_zero" json:"messages"`
+	Comments    int       `pg:",use_zero" json:"comments"`
+	Joins       int       `pg:",use_zero" json:"joins"`
+	Score       float64   `pg:",use_zero" json:"score"`
+	TimeUpdated time.Time `pg:",notnull,default:now()" json:"time_updated"`
+}
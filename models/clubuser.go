@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/Krishap-s/keats-backend/redisclient"
 	"github.com/go-pg/pg/v10"
@@ -12,9 +13,11 @@ import (
 )
 
 type ClubUser struct {
-	ID     uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	ClubID uuid.UUID `pg:"type:uuid,nopk,notnull,unique:clubuser" json:"room_id"`
-	UserID uuid.UUID `pg:"type:uuid,nopk,notnull,unique:clubuser" json:"user_id"`
+	ID          uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ClubID      uuid.UUID `pg:"type:uuid,nopk,notnull,unique:clubuser" json:"room_id"`
+	UserID      uuid.UUID `pg:"type:uuid,nopk,notnull,unique:clubuser" json:"user_id"`
+	TimeCreated time.Time `pg:",notnull,default:now()" json:"time_created"`
+	Muted       bool      `pg:",notnull,use_zero,default:false" json:"muted"`
 }
 
 var _ pg.AfterInsertHook = (*ClubUser)(nil)
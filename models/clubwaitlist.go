@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClubWaitlist represents a user waiting for a seat in a full club
+type ClubWaitlist struct {
+	ID          uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ClubID      uuid.UUID `pg:"type:uuid,nopk,notnull,unique:clubwaitlist" json:"club_id"`
+	UserID      uuid.UUID `pg:"type:uuid,nopk,notnull,unique:clubwaitlist" json:"user_id"`
+	TimeCreated time.Time `pg:",notnull,default:now()" json:"time_created"`
+}
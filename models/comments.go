@@ -1,14 +1,47 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Krishap-s/keats-backend/redisclient"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
 
 // Comment represents a commnent in the database
 type Comment struct {
-	ID       uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	ClubID   uuid.UUID `pg:"type:uuid,notnull,nopk" json:"club_id"`
-	ParentID uuid.UUID `pg:"type:uuid,nopk" json:"parent_id"`
-	UserID   uuid.UUID `pg:"type:uuid,notnull,nopk" json:"user_id"`
-	PageNo   int       `pg:",notnull" json:"page_no"`
-	Message  string    `pg:",notnull" json:"message"`
-	Likes    int       `pg:",notnull,default:0" json:"likes"`
+	ID          uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ClubID      uuid.UUID `pg:"type:uuid,notnull,nopk" json:"club_id"`
+	ParentID    uuid.UUID `pg:"type:uuid,nopk" json:"parent_id"`
+	UserID      uuid.UUID `pg:"type:uuid,notnull,nopk" json:"user_id"`
+	PageNo      int       `pg:",notnull" json:"page_no"`
+	Message     string    `pg:",notnull" json:"message"`
+	Likes       int       `pg:",notnull,default:0" json:"likes"`
+	TimeCreated time.Time `pg:",notnull,default:now()" json:"time_created"`
+	Hidden      bool      `pg:",notnull,use_zero,default:false" json:"-"`
+}
+
+var _ Hideable = (*Comment)(nil)
+
+// PublishHidden publishes to websocket clients that the comment was hidden or restored by a moderator
+func (m *Comment) PublishHidden(ctx context.Context) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	var byteData []byte
+	byteData, err = json.Marshal(fiber.Map{
+		"action":     "comment_hidden",
+		"comment_id": m.ID,
+		"hidden":     m.Hidden,
+	})
+	if err != nil {
+		log.Println("Publish error:", err)
+		return nil
+	}
+	rdb.Publish(ctx, m.ClubID.String(), byteData)
+	return nil
 }
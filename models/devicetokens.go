@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceToken represents a device of a user registered for push notifications
+type DeviceToken struct {
+	Token       string    `pg:",pk" json:"token"`
+	UserID      uuid.UUID `pg:"type:uuid,nopk,notnull" json:"user_id"`
+	Platform    string    `json:"platform"`
+	TimeCreated time.Time `pg:",notnull,default:now()" json:"time_created"`
+	TimeUpdated time.Time `pg:",notnull,default:now()" json:"time_updated"`
+}
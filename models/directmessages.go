@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Krishap-s/keats-backend/redisclient"
+	"github.com/go-pg/pg/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Conversation represents a one-to-one conversation between two users in the
+// database, UserAID is always the smaller of the two ids
+type Conversation struct {
+	ID          uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	UserAID     uuid.UUID `pg:"type:uuid,nopk,notnull,unique:conversation" json:"user_a_id"`
+	UserBID     uuid.UUID `pg:"type:uuid,nopk,notnull,unique:conversation" json:"user_b_id"`
+	TimeCreated time.Time `pg:",notnull,default:now()" json:"time_created"`
+}
+
+// DirectMessage represents a message sent in a conversation in the database
+type DirectMessage struct {
+	ID             uuid.UUID  `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ConversationID uuid.UUID  `pg:"type:uuid,notnull,nopk" json:"conversation_id"`
+	SenderID       uuid.UUID  `pg:"type:uuid,notnull,nopk" json:"sender_id"`
+	RecipientID    uuid.UUID  `pg:"type:uuid,notnull,nopk" json:"recipient_id"`
+	Message        string     `pg:",notnull" json:"message"`
+	TimeCreated    time.Time  `pg:",notnull,default:now()" json:"time_created"`
+	TimeRead       *time.Time `json:"time_read"`
+}
+
+// UserChannel returns the pubsub channel of the personal websocket of a user
+func UserChannel(userID string) string {
+	return "user_" + userID
+}
+
+var _ pg.AfterInsertHook = (*DirectMessage)(nil)
+
+// AfterInsert hook publishes the direct message to the personal websockets of both users
+func (m *DirectMessage) AfterInsert(ctx context.Context) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	var byteData []byte
+	byteData, err = json.Marshal(fiber.Map{
+		"user_id": m.SenderID.String(),
+		"action":  "direct_message",
+		"data":    m,
+	})
+	if err != nil {
+		log.Println("Hook error:", err)
+		return nil
+	}
+	rdb.Publish(ctx, UserChannel(m.SenderID.String()), byteData)
+	rdb.Publish(ctx, UserChannel(m.RecipientID.String()), byteData)
+	return nil
+}
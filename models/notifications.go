@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Krishap-s/keats-backend/redisclient"
+	"github.com/go-pg/pg/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Types of notifications
+const (
+	NotificationReply        = "reply"
+	NotificationMention      = "mention"
+	NotificationKick         = "kick"
+	NotificationRoleChange   = "role_change"
+	NotificationClubUpdate   = "club_update"
+	NotificationWaitlistJoin = "waitlist_join"
+	NotificationPageSync     = "page_sync"
+	// Chat messages are only pushed, never kept in the inbox
+	NotificationChat = "chat"
+)
+
+// Notification represents an event delivered to a single user in the database
+type Notification struct {
+	ID          uuid.UUID              `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID              `pg:"type:uuid,notnull,nopk" json:"user_id"`
+	ActorID     uuid.UUID              `pg:"type:uuid,nopk" json:"actor_id"`
+	ClubID      uuid.UUID              `pg:"type:uuid,nopk" json:"club_id"`
+	Type        string                 `pg:",notnull" json:"type"`
+	Data        map[string]interface{} `pg:"type:jsonb" json:"data"`
+	TimeCreated time.Time              `pg:",notnull,default:now()" json:"time_created"`
+	TimeRead    *time.Time             `json:"time_read"`
+	// Title is filled in for the locale of the reader when notifications are listed
+	Title string `pg:"-" json:"title,omitempty"`
+}
+
+var _ pg.AfterInsertHook = (*Notification)(nil)
+
+// AfterInsert hook publishes the notification to the personal websockets of its user
+func (n *Notification) AfterInsert(ctx context.Context) error {
+	rdb, err := redisclient.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	var byteData []byte
+	byteData, err = json.Marshal(fiber.Map{
+		"action": "notification",
+		"data":   n,
+	})
+	if err != nil {
+		log.Println("Hook error:", err)
+		return nil
+	}
+	rdb.Publish(ctx, UserChannel(n.UserID.String()), byteData)
+	return nil
+}
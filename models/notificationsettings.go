@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// NotificationSetting represents the notification preferences of a user,
+// ClubID is nil for the defaults of the user and set for the overrides of a
+// single club, where nil preferences fall back to the defaults. Quiet hours
+// and the timezone are only read from the defaults.
+type NotificationSetting struct {
+	ID          uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"-"`
+	UserID      uuid.UUID `pg:"type:uuid,nopk,notnull,unique:notificationsetting" json:"-"`
+	ClubID      uuid.UUID `pg:"type:uuid,nopk,notnull,unique:notificationsetting" json:"club_id"`
+	Mentions    *bool     `json:"mentions"`
+	Replies     *bool     `json:"replies"`
+	Chat        *bool     `json:"chat"`
+	PageSync    *bool     `json:"page_sync"`
+	ClubUpdates *bool     `json:"club_updates"`
+	QuietStart  string    `json:"quiet_start"`
+	QuietEnd    string    `json:"quiet_end"`
+	Timezone    string    `json:"timezone"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneNoHistory represents a past change of a user's phone number, kept for support
+type PhoneNoHistory struct {
+	ID          uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID `pg:"type:uuid,notnull,nopk" json:"user_id"`
+	OldPhoneNo  string    `json:"old_phone_number"`
+	NewPhoneNo  string    `pg:",notnull" json:"new_phone_number"`
+	TimeChanged time.Time `pg:",notnull,default:now()" json:"time_changed"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of content that can be reported
+const (
+	ReportChatMessage = "chatmessage"
+	ReportComment     = "comment"
+	ReportUser        = "user"
+	ReportClub        = "club"
+)
+
+// Hideable is content moderators can hide from everyone, clients are told of
+// the change once it is committed
+type Hideable interface {
+	PublishHidden(ctx context.Context) error
+}
+
+// States of a report in the moderation queue
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// Report represents a member flagging content or another user for moderation
+type Report struct {
+	ID           uuid.UUID  `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ReporterID   uuid.UUID  `pg:"type:uuid,nopk,notnull,unique:report" json:"reporter_id"`
+	TargetType   string     `pg:",notnull,unique:report" json:"target_type"`
+	TargetID     uuid.UUID  `pg:"type:uuid,nopk,notnull,unique:report" json:"target_id"`
+	ClubID       uuid.UUID  `pg:"type:uuid,nopk" json:"club_id"`
+	Reason       string     `pg:",notnull" json:"reason"`
+	Status       string     `pg:",notnull,default:'open'" json:"status"`
+	ReviewerID   uuid.UUID  `pg:"type:uuid,nopk" json:"reviewer_id"`
+	Resolution   string     `json:"resolution"`
+	TimeCreated  time.Time  `pg:",notnull,default:now()" json:"time_created"`
+	TimeReviewed *time.Time `json:"time_reviewed"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "github.com/google/uuid"
+
+// Categories are the curated tags offered to every club
+var Categories = []string{
+	"fiction",
+	"non-fiction",
+	"fantasy",
+	"science-fiction",
+	"mystery",
+	"romance",
+	"horror",
+	"biography",
+	"history",
+	"poetry",
+	"self-help",
+	"science",
+	"philosophy",
+	"comics",
+	"young-adult",
+}
+
+// Tag represents a tag that clubs can be labelled with
+type Tag struct {
+	ID      uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	Name    string    `pg:",unique,notnull" json:"name"`
+	Curated bool      `pg:",use_zero" json:"curated"`
+}
+
+// ClubTag represents a tag attached to a club
+type ClubTag struct {
+	ID     uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	ClubID uuid.UUID `pg:"type:uuid,nopk,notnull,unique:clubtag" json:"club_id"`
+	TagID  uuid.UUID `pg:"type:uuid,nopk,notnull,unique:clubtag" json:"tag_id"`
+}
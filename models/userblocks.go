@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserBlock represents a user hiding another user from themselves
+type UserBlock struct {
+	ID          uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID `pg:"type:uuid,nopk,notnull,unique:userblock" json:"user_id"`
+	BlockedID   uuid.UUID `pg:"type:uuid,nopk,notnull,unique:userblock" json:"blocked_id"`
+	TimeCreated time.Time `pg:",notnull,default:now()" json:"time_created"`
+}
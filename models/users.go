@@ -1,13 +1,36 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles of users across the whole platform
+const (
+	RoleMember    = "member"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
 
 // User represents a user in the database
 type User struct {
-	ID         uuid.UUID `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
-	Username   string    `pg:",notnull" json:"username"`
-	PhoneNo    string    `pg:",unique,notnull" json:"phone_number"`
-	ProfilePic string    `pg:",default:'https://i.ibb.co/drJX0MS/default-photo.jpg'" json:"profile_pic"`
-	Email      string    `json:"email"`
-	Bio        string    `json:"bio"`
+	ID             uuid.UUID  `pg:",pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+	Username       string     `pg:",notnull" json:"username"`
+	Handle         string     `pg:",unique" json:"handle"`
+	PhoneNo        string     `pg:",unique" json:"phone_number"`
+	ProfilePic     string     `pg:",default:'https://i.ibb.co/drJX0MS/default-photo.jpg'" json:"profile_pic"`
+	Email          string     `json:"email"`
+	EmailVerified  bool       `pg:",use_zero" json:"email_verified"`
+	Bio            string     `json:"bio"`
+	TokenVersion   int        `pg:",use_zero" json:"-"`
+	Role           string     `pg:",notnull,default:'member'" json:"-"`
+	SuspendedUntil *time.Time `json:"-"`
+	// Locale is the language the user reads messages in, empty to follow their client
+	Locale string `json:"locale"`
+}
+
+// IsSuspended reports whether the user is barred from signing in
+func (u *User) IsSuspended() bool {
+	return u.SuspendedUntil != nil && u.SuspendedUntil.After(time.Now())
 }
@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// contractLog turns on checking responses against the spec when set as
+// OPENAPI_CONTRACT_CHECK, logging where they drift from it
+const contractLog = "log"
+
+// unlisted are routes served outside of the REST API the spec describes, and
+// relativeUnlisted those served under the prefix of every version
+var (
+	unlisted         = []string{"/", "/api/asyncapi.json", "/api/ws"}
+	relativeUnlisted = []string{"/openapi.json"}
+)
+
+func isUnlisted(list []string, path string) bool {
+	for _, prefix := range list {
+		if path == prefix || (prefix != "/" && strings.HasPrefix(path, prefix+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPrefix splits path into the longest of prefixes it is under and the
+// rest of it, returning an empty prefix when it is under none
+func splitPrefix(path string, prefixes []string) (string, string) {
+	longest := ""
+	for _, prefix := range prefixes {
+		if (path == prefix || strings.HasPrefix(path, prefix+"/")) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+	if longest == "" {
+		return "", path
+	}
+	return longest, strings.TrimPrefix(path, longest)
+}
+
+// specPath turns the path of a fiber route into the path of the spec, e.g.
+// /user/:id into /user/{id}
+func specPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// handlerKey identifies a route by its path and handlers
+func handlerKey(route *fiber.Route) string {
+	key := route.Path
+	for _, handler := range route.Handlers {
+		key += fmt.Sprintf(" %x", reflect.ValueOf(handler).Pointer())
+	}
+	return key
+}
+
+// Drift compares the routes served by app under each of prefixes, the
+// prefixes of the versions of the API, with the spec and returns every route
+// missing from one or the other
+func Drift(app *fiber.App, prefixes ...string) []string {
+	var routes []*fiber.Route
+	// Middleware mounted with Use is registered under every method, TRACE
+	// included, which no route of the API is served with
+	middleware := map[string]bool{}
+	for _, methodRoutes := range app.Stack() {
+		for _, route := range methodRoutes {
+			if route.Method == fiber.MethodTrace {
+				middleware[handlerKey(route)] = true
+			}
+			routes = append(routes, route)
+		}
+	}
+	served := map[string]bool{}
+	var drift []string
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead || route.Method == fiber.MethodTrace ||
+			middleware[handlerKey(route)] || isUnlisted(unlisted, route.Path) {
+			continue
+		}
+		prefix, path := splitPrefix(route.Path, prefixes)
+		if isUnlisted(relativeUnlisted, path) {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		if prefix == "" {
+			drift = append(drift, key+" is served outside of the versions of the API")
+			continue
+		}
+		served[route.Method+" "+prefix+specPath(path)] = true
+		if Spec().Find(route.Method, path) == nil {
+			drift = append(drift, key+" is served but missing from the spec")
+		}
+	}
+	for _, prefix := range prefixes {
+		for _, op := range Operations {
+			if key := op.Method + " " + prefix + op.Path; !served[key] {
+				drift = append(drift, key+" is in the spec but not served")
+			}
+		}
+	}
+	return drift
+}
+
+// CheckRoutes logs every route served by app under each of prefixes that
+// drifts from the spec, the tests of this package fail on them
+func CheckRoutes(app *fiber.App, prefixes ...string) {
+	for _, d := range Drift(app, prefixes...) {
+		log.Println("OpenAPI drift:", d)
+	}
+}
+
+// Contract returns a middleware that checks successful JSON responses against
+// the spec when OPENAPI_CONTRACT_CHECK is log, logging those that drift from
+// it. specOf returns the document of the version of the API a request was
+// served by and the path of its route relative to the prefix of the version,
+// a nil document for requests outside of the API.
+func Contract(specOf func(c *fiber.Ctx) (*Document, string)) fiber.Handler {
+	if viper.GetString("OPENAPI_CONTRACT_CHECK") != contractLog {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		status := c.Response().StatusCode()
+		contentType := string(c.Response().Header.ContentType())
+		if status < 200 || status > 299 || !strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) {
+			return nil
+		}
+		doc, path := specOf(c)
+		if doc == nil {
+			return nil
+		}
+		route := c.Route()
+		entry := doc.Find(route.Method, path)
+		if entry == nil {
+			return nil
+		}
+		if violations := doc.CheckResponse(entry, c.Response().Body()); len(violations) != 0 {
+			log.Println("Contract violation:", route.Method, route.Path, strings.Join(violations, "; "))
+		}
+		return nil
+	}
+}
+
+// CheckResponse returns where a successful response body of an operation of
+// the document differs from it
+func (d *Document) CheckResponse(entry *PathEntry, body []byte) []string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{"body is not JSON: " + err.Error()}
+	}
+	schema := entry.Responses["200"].Content[fiber.MIMEApplicationJSON].Schema
+	violations := Check(schema, d.Components.Schemas, value, "$")
+	sort.Strings(violations)
+	return violations
+}
+
+// Check returns where the decoded JSON value differs from the schema s, with
+// references resolved against components and places named by their path from path
+func Check(s *Schema, components map[string]*Schema, value interface{}, path string) []string {
+	if s.Ref != "" {
+		return Check(components[strings.TrimPrefix(s.Ref, "#/components/schemas/")], components, value, path)
+	}
+	if value == nil {
+		if s.Nullable || (s.Type == "" && len(s.AllOf) == 0) {
+			return nil
+		}
+		return []string{path + " is null"}
+	}
+	var violations []string
+	for _, sub := range s.AllOf {
+		violations = append(violations, Check(sub, components, value, path)...)
+	}
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(violations, path+" is not an object")
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				violations = append(violations, path+"."+name+" is missing")
+			}
+		}
+		for name, property := range object {
+			propertySchema, ok := s.Properties[name]
+			if !ok {
+				propertySchema = s.AdditionalProperties
+			}
+			if propertySchema == nil {
+				violations = append(violations, path+"."+name+" is not in the spec")
+				continue
+			}
+			violations = append(violations, Check(propertySchema, components, property, path+"."+name)...)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(violations, path+" is not an array")
+		}
+		for i, item := range items {
+			violations = append(violations, Check(s.Items, components, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return append(violations, path+" is not a string")
+		}
+		violations = append(violations, checkString(s, str, path)...)
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return append(violations, path+" is not an integer")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return append(violations, path+" is not a number")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(violations, path+" is not a boolean")
+		}
+	}
+	return violations
+}
+
+func checkString(s *Schema, str string, path string) []string {
+	switch s.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+			return []string{path + " is not a date-time"}
+		}
+	case "uuid":
+		if _, err := uuid.Parse(str); err != nil {
+			return []string{path + " is not a uuid"}
+		}
+	}
+	if len(s.Enum) == 0 {
+		return nil
+	}
+	for _, option := range s.Enum {
+		if str == option {
+			return nil
+		}
+	}
+	return []string{path + " is not one of " + strings.Join(s.Enum, ", ")}
+}
@@ -0,0 +1,114 @@
+package openapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/api/server"
+	"github.com/Krishap-s/keats-backend/api/server/servertest"
+	"github.com/Krishap-s/keats-backend/api/versions"
+	"github.com/Krishap-s/keats-backend/openapi"
+)
+
+func TestRoutesMatchSpec(t *testing.T) {
+	servertest.Configure()
+	app := server.New()
+	for _, drift := range openapi.Drift(app, versions.Prefixes()...) {
+		t.Error(drift)
+	}
+}
+
+func TestEveryOperationHasACase(t *testing.T) {
+	cases := map[string]bool{}
+	for _, c := range operationCases {
+		cases[c.method+" "+c.path] = true
+	}
+	operations := map[string]bool{}
+	for _, op := range openapi.Operations {
+		key := op.Method + " " + op.Path
+		operations[key] = true
+		if !cases[key] {
+			t.Errorf("%s has no case in operationCases", key)
+		}
+	}
+	for key := range cases {
+		if !operations[key] {
+			t.Errorf("%s has a case but is not in Operations", key)
+		}
+	}
+}
+
+// TestOperations requests every operation under the prefix of each version
+// of the API and checks the response against the document of the version
+func TestOperations(t *testing.T) {
+	servertest.Setup(t)
+	app := server.New()
+	for _, c := range operationCases {
+		c := c
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			if c.skip != "" {
+				t.Skip(c.skip)
+			}
+			for _, mount := range versions.Mounts {
+				mount := mount
+				t.Run(mount.Prefix, func(t *testing.T) {
+					checkOperation(t, app, mount, c)
+				})
+			}
+		})
+	}
+}
+
+// checkOperation makes the request of c to the operation served under mount
+// and checks that it succeeds with a response matching the document
+func checkOperation(t *testing.T, app *fiber.App, mount *versions.Mount, c operationCase) {
+	r := c.prepare(t)
+	target := mount.Prefix + c.path
+	if r.path != "" {
+		target = mount.Prefix + r.path
+	}
+	if len(r.query) != 0 {
+		target += "?" + r.query.Encode()
+	}
+	var body io.Reader
+	if r.body != nil {
+		b, err := json.Marshal(r.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(c.method, target, body)
+	if r.body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if r.token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+r.token)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("%s %s returned %d: %s", c.method, target, resp.StatusCode, respBody)
+	}
+	doc := versions.Specs()[mount]
+	entry := doc.Find(c.method, c.path)
+	if entry == nil {
+		t.Fatalf("%s %s is missing from the document of %s", c.method, c.path, mount.Prefix)
+	}
+	for _, violation := range doc.CheckResponse(entry, respBody) {
+		t.Error(violation)
+	}
+}
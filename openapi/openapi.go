@@ -0,0 +1,337 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Krishap-s/keats-backend/errors"
+	"github.com/Krishap-s/keats-backend/validation"
+)
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Tags       []Tag                            `json:"tags"`
+	Paths      map[string]map[string]*PathEntry `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// Server is a prefix the paths of the document are served under
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathEntry is an operation object of OpenAPI, one method of a path
+type PathEntry struct {
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary"`
+	OperationID string                `json:"operationId"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *Body                 `json:"requestBody,omitempty"`
+	Responses   map[string]*Body      `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path or query parameter of an operation
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// Body is a request body or a response
+type Body struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds what is referenced from the rest of the document
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	Responses       map[string]*Body           `json:"responses"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how requests are authenticated
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat"`
+}
+
+const description = "REST API of Keats. Successful responses are wrapped as " +
+	`{"status": "success", "data": ..., "message": ...} and errors are reported as ` +
+	`{"status": "error", "code": ..., "message": ..., "details": ..., "request_id": ...} ` +
+	"where code is stable and message is in the locale of the user."
+
+var (
+	document     *Document
+	documentOnce sync.Once
+)
+
+// Spec returns the OpenAPI document of the REST API, built from Operations
+// and the types of the schemas and models packages. Its paths are relative
+// to the prefix of a version of the API and its schemas are the shapes the
+// handlers answer in.
+func Spec() *Document {
+	documentOnce.Do(func() {
+		document = build()
+	})
+	return document
+}
+
+// Clone returns a deep copy of the document, to be changed without changing d
+func (d *Document) Clone() *Document {
+	b, err := json.Marshal(d)
+	if err != nil {
+		panic(err)
+	}
+	clone := new(Document)
+	if err = json.Unmarshal(b, clone); err != nil {
+		panic(err)
+	}
+	return clone
+}
+
+// Find returns the operation served by the fiber route of method and path,
+// e.g. GET /user/:id, nil when the document has none
+func (d *Document) Find(method string, path string) *PathEntry {
+	return d.Paths[specPath(path)][strings.ToLower(method)]
+}
+
+func build() *Document {
+	g := NewGenerator()
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Keats API",
+			Description: description,
+			Version:     "1.0.0",
+		},
+		Paths: map[string]map[string]*PathEntry{},
+	}
+	tags := map[string]bool{}
+	for _, op := range Operations {
+		if doc.Paths[op.Path] == nil {
+			doc.Paths[op.Path] = map[string]*PathEntry{}
+		}
+		doc.Paths[op.Path][strings.ToLower(op.Method)] = g.entry(op)
+		if !tags[op.Tag] {
+			tags[op.Tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: op.Tag})
+		}
+	}
+	g.SchemaOf(validation.FieldError{}, false)
+	g.components["Error"] = errorSchema()
+	doc.Components = Components{
+		Schemas: g.components,
+		Responses: map[string]*Body{
+			"Error": {
+				Description: "The request failed",
+				Content: map[string]*MediaType{
+					fiber.MIMEApplicationJSON: {Schema: &Schema{Ref: "#/components/schemas/Error"}},
+				},
+			},
+		},
+		SecuritySchemes: map[string]*SecurityScheme{
+			"jwt": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		},
+	}
+	return doc
+}
+
+// entry describes an operation as an operation object of OpenAPI
+func (g *Generator) entry(op *Operation) *PathEntry {
+	e := &PathEntry{
+		Tags:        []string{op.Tag},
+		Summary:     op.Summary,
+		OperationID: operationID(op),
+		Responses: map[string]*Body{
+			"200": {
+				Description: "The request succeeded",
+				Content: map[string]*MediaType{
+					fiber.MIMEApplicationJSON: {Schema: g.responseSchema(op)},
+				},
+			},
+			"default": {Ref: "#/components/responses/Error"},
+		},
+		Security: []map[string][]string{{"jwt": {}}},
+	}
+	if op.Public {
+		e.Security = []map[string][]string{}
+	}
+	for _, segment := range strings.Split(op.Path, "/") {
+		if strings.HasPrefix(segment, "{") {
+			e.Parameters = append(e.Parameters, &Parameter{
+				Name:     strings.Trim(segment, "{}"),
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	e.Parameters = append(e.Parameters, g.queryParameters(op.Query)...)
+	if op.Request != nil {
+		e.RequestBody = &Body{Required: true, Content: g.requestContent(op)}
+	}
+	return e
+}
+
+// queryParameters describes the query parameters of an operation
+func (g *Generator) queryParameters(query interface{}) []*Parameter {
+	var parameters []*Parameter
+	if object, ok := query.(Object); ok {
+		for _, name := range sortedKeys(object) {
+			parameters = append(parameters, &Parameter{
+				Name:   name,
+				In:     "query",
+				Schema: g.SchemaOf(object[name], true),
+			})
+		}
+		return parameters
+	}
+	if query == nil {
+		return nil
+	}
+	t := reflect.TypeOf(query)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" {
+			continue
+		}
+		validateTag := field.Tag.Get("validate")
+		s := g.typeSchema(field.Type, true)
+		constrain(s, field.Type, validateTag)
+		parameters = append(parameters, &Parameter{
+			Name:     name,
+			In:       "query",
+			Required: hasRule(validateTag, "required"),
+			Schema:   s,
+		})
+	}
+	return parameters
+}
+
+// operationID names an operation after its method and path, e.g. postApiClubsJoin
+func operationID(op *Operation) string {
+	id := strings.ToLower(op.Method)
+	for _, word := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}'
+	}) {
+		id += strings.Title(word)
+	}
+	return id
+}
+
+// requestContent describes the body of a request as JSON, or as multipart
+// form data too when files are uploaded with it
+func (g *Generator) requestContent(op *Operation) map[string]*MediaType {
+	s := g.SchemaOf(op.Request, true)
+	content := map[string]*MediaType{fiber.MIMEApplicationJSON: {Schema: s}}
+	if len(op.Files) != 0 {
+		form := &Schema{Type: "object", Properties: map[string]*Schema{}, Required: s.Required}
+		for name, property := range s.Properties {
+			form.Properties[name] = property
+		}
+		for _, name := range op.Files {
+			form.Properties[name] = &Schema{Type: "string", Format: "binary"}
+		}
+		content[fiber.MIMEMultipartForm] = &MediaType{Schema: form}
+	}
+	return content
+}
+
+// responseSchema describes the response of a successful request
+func (g *Generator) responseSchema(op *Operation) *Schema {
+	if op.Raw {
+		return g.SchemaOf(op.Data, false)
+	}
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"status": {Type: "string", Enum: []string{"success"}},
+		},
+		Required: []string{"status"},
+	}
+	if op.Data != nil {
+		s.Properties["data"] = g.SchemaOf(op.Data, false)
+		s.Required = append(s.Required, "data")
+	}
+	if op.Message {
+		s.Properties["message"] = &Schema{Type: "string"}
+		s.Required = append(s.Required, "message")
+	}
+	for name, value := range op.Fields {
+		s.Properties[name] = g.SchemaOf(value, false)
+		s.Required = append(s.Required, name)
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// errorSchema describes the body of failed requests, with every code the API reports
+func errorSchema() *Schema {
+	var codes []string
+	for _, err := range errors.All() {
+		codes = append(codes, err.Code)
+	}
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"status":     {Type: "string", Enum: []string{"error"}},
+			"code":       {Type: "string", Enum: codes},
+			"message":    {Type: "string"},
+			"details":    {Type: "array", Nullable: true, Items: &Schema{Ref: "#/components/schemas/validation.FieldError"}},
+			"request_id": {Type: "string"},
+		},
+		Required: []string{"code", "details", "message", "request_id", "status"},
+	}
+}
+
+func sortedKeys(object Object) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func without(list []string, item string) []string {
+	var kept []string
+	for _, s := range list {
+		if s != item {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,124 @@
+package openapi
+
+import (
+	"github.com/Krishap-s/keats-backend/models"
+	"github.com/Krishap-s/keats-backend/schemas"
+)
+
+// Operation describes a route of the REST API and what it reads and returns.
+// Responses are described inside the envelope every handler answers with,
+// {"status": "success", "data": ..., "message": ...}, unless Raw is set.
+type Operation struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	// Public operations are served without a JWT
+	Public bool
+	// Query lists the query parameters read by the operation as an Object, or
+	// as a struct with query tags when the handler parses them into one
+	Query interface{}
+	// Request is the body read by the operation, nil when it reads none
+	Request interface{}
+	// Files lists the files uploaded with the request, which is then sent as multipart form data
+	Files []string
+	// Data is what the operation returns under data, nil when it returns none
+	Data interface{}
+	// Message is set when the response carries a message for people
+	Message bool
+	// Fields lists the top level fields of the response besides status, data and message
+	Fields Object
+	// Raw is set when the response is Data itself rather than an envelope
+	Raw bool
+}
+
+var (
+	pageQuery   = Object{"page": 0}
+	clubIDBody  = Object{"club_id": ""}
+	userIDBody  = Object{"user_id": ""}
+	idTokenBody = Object{"id_token": ""}
+	tokenData   = Object{"token": "", "user_id": ""}
+	clubData    = Object{
+		"club":     (*schemas.Club)(nil),
+		"users":    []*schemas.PublicUser(nil),
+		"comments": []*schemas.Comment(nil),
+		"chat":     []*schemas.ChatMessage(nil),
+	}
+)
+
+// Operations lists every route of the REST API. Add new routes here along
+// with their handlers, the server reports routes missing from this list when
+// it starts.
+var Operations = []*Operation{
+	// Users
+	{Method: "POST", Path: "/user", Tag: "users", Summary: "Sign up or sign in with a Firebase phone number ID token", Public: true, Request: idTokenBody, Data: tokenData},
+	{Method: "POST", Path: "/user/email/code", Tag: "users", Summary: "Send a sign in code to an email", Public: true, Request: schemas.EmailCodeRequest{}, Message: true},
+	{Method: "POST", Path: "/user/email/login", Tag: "users", Summary: "Sign in with a code sent to an email", Public: true, Request: schemas.EmailCodeVerify{}, Data: tokenData},
+	{Method: "GET", Path: "/user", Tag: "users", Summary: "Get the signed in user", Data: (*models.User)(nil)},
+	{Method: "PATCH", Path: "/user", Tag: "users", Summary: "Update the signed in user", Request: Partial(schemas.UserUpdate{}, "id", "phone_number", "email", "profile_pic"), Files: []string{"profile_pic"}, Data: (*models.User)(nil)},
+	{Method: "DELETE", Path: "/user", Tag: "users", Summary: "Delete the signed in user", Query: Object{"content": ""}, Data: (*schemas.UserDelete)(nil), Message: true},
+	{Method: "POST", Path: "/user/updatephone", Tag: "users", Summary: "Start changing the phone number of the signed in user", Request: idTokenBody, Data: Object{"change_id": "", "phone_number": ""}, Message: true},
+	{Method: "POST", Path: "/user/updatephone/confirm", Tag: "users", Summary: "Confirm a phone number change", Request: Object{"change_id": ""}, Data: tokenData, Message: true},
+	{Method: "GET", Path: "/user/clubs", Tag: "users", Summary: "Get the signed in user and their clubs", Query: pageQuery, Data: Object{"clubs": []*schemas.Club(nil), "user": models.User{}}},
+	{Method: "GET", Path: "/user/export", Tag: "users", Summary: "Export all data kept about the signed in user, as a zip archive when format is zip", Query: Object{"format": ""}, Data: schemas.UserExport{}, Raw: true},
+	{Method: "POST", Path: "/user/email/verify/code", Tag: "users", Summary: "Send a code to verify an email", Request: schemas.EmailCodeRequest{}, Message: true},
+	{Method: "POST", Path: "/user/email/verify", Tag: "users", Summary: "Verify an email with a code sent to it", Request: schemas.EmailCodeVerify{}, Data: (*models.User)(nil), Message: true},
+	{Method: "GET", Path: "/user/blocks", Tag: "users", Summary: "List users blocked by the signed in user", Data: []*schemas.PublicUser(nil)},
+	{Method: "POST", Path: "/user/block", Tag: "users", Summary: "Block a user", Request: userIDBody, Message: true},
+	{Method: "POST", Path: "/user/unblock", Tag: "users", Summary: "Unblock a user", Request: userIDBody, Message: true},
+	{Method: "GET", Path: "/user/handle", Tag: "users", Summary: "Check whether a handle is available", Query: Object{"handle": ""}, Data: Object{"handle": "", "available": false}},
+	{Method: "POST", Path: "/user/devices", Tag: "users", Summary: "Register a device for push notifications", Request: schemas.DeviceTokenCreate{}, Message: true},
+	{Method: "DELETE", Path: "/user/devices", Tag: "users", Summary: "Unregister a device from push notifications", Request: schemas.DeviceTokenCreate{}, Message: true},
+	{Method: "GET", Path: "/user/{id}", Tag: "users", Summary: "Get the public profile of a user", Data: (*schemas.UserProfile)(nil)},
+
+	// Clubs
+	{Method: "GET", Path: "/clubs", Tag: "clubs", Summary: "Get a club with its members, comments and chat", Query: Object{"club_id": ""}, Data: clubData},
+	{Method: "GET", Path: "/clubs/list", Tag: "clubs", Summary: "List public clubs", Query: Object{"page": 0, "tag": ""}, Data: []*schemas.Club(nil)},
+	{Method: "GET", Path: "/clubs/discover", Tag: "clubs", Summary: "Search public clubs", Query: schemas.ClubSearch{}, Data: (*schemas.ClubPage)(nil)},
+	{Method: "GET", Path: "/clubs/trending", Tag: "clubs", Summary: "List trending clubs", Query: pageQuery, Data: []*schemas.ClubRecommendation(nil)},
+	{Method: "GET", Path: "/clubs/recommended", Tag: "clubs", Summary: "List clubs recommended for the signed in user", Query: pageQuery, Data: []*schemas.ClubRecommendation(nil)},
+	{Method: "GET", Path: "/clubs/categories", Tag: "clubs", Summary: "List club categories", Data: []*schemas.Tag(nil)},
+	{Method: "GET", Path: "/clubs/tags", Tag: "clubs", Summary: "Autocomplete club tags", Query: Object{"q": ""}, Data: []*schemas.Tag(nil)},
+	{Method: "GET", Path: "/clubs/audit", Tag: "clubs", Summary: "List the audit log of a club", Query: Object{"club_id": "", "page": 0}, Data: []*models.AuditLog(nil)},
+	{Method: "POST", Path: "/clubs/create", Tag: "clubs", Summary: "Create a club", Request: Partial(schemas.ClubCreate{}, "id", "host_id", "club_pic", "file_url"), Files: []string{"club_pic", "file"}, Data: (*models.Club)(nil)},
+	{Method: "POST", Path: "/clubs/join", Tag: "clubs", Summary: "Join a club", Request: clubIDBody, Data: clubData, Message: true},
+	{Method: "PATCH", Path: "/clubs/update", Tag: "clubs", Summary: "Update a club", Request: Partial(schemas.ClubUpdate{}, "host_id", "club_pic", "file_url"), Files: []string{"club_pic", "file"}, Data: (*models.Club)(nil)},
+	{Method: "POST", Path: "/clubs/toggleprivate", Tag: "clubs", Summary: "Make a club private or public", Request: clubIDBody, Message: true},
+	{Method: "POST", Path: "/clubs/togglesync", Tag: "clubs", Summary: "Turn page sync of a club on or off", Request: clubIDBody, Message: true},
+	{Method: "POST", Path: "/clubs/togglearchive", Tag: "clubs", Summary: "Archive or unarchive a club", Request: clubIDBody, Message: true},
+	{Method: "POST", Path: "/clubs/delete", Tag: "clubs", Summary: "Delete a club", Request: clubIDBody, Message: true},
+	{Method: "POST", Path: "/clubs/kickuser", Tag: "clubs", Summary: "Kick a user out of a club", Request: Object{"club_id": "", "user_id": ""}, Message: true},
+	{Method: "POST", Path: "/clubs/leave", Tag: "clubs", Summary: "Leave a club", Request: clubIDBody, Message: true},
+	{Method: "POST", Path: "/clubs/mute", Tag: "clubs", Summary: "Mute or unmute notifications from a club", Request: Object{"club_id": "", "muted": false}, Message: true},
+	{Method: "POST", Path: "/clubs/filter", Tag: "clubs", Summary: "Set how strictly a club filters messages", Request: Object{"club_id": "", "strictness": ""}, Message: true},
+
+	// Conversations
+	{Method: "GET", Path: "/conversations", Tag: "conversations", Summary: "List conversations of the signed in user", Data: []*schemas.Conversation(nil)},
+	{Method: "POST", Path: "/conversations", Tag: "conversations", Summary: "Start a conversation with a user", Request: userIDBody, Data: (*models.Conversation)(nil)},
+	{Method: "GET", Path: "/conversations/{id}/messages", Tag: "conversations", Summary: "List messages of a conversation", Query: Object{"before": ""}, Data: []*schemas.DirectMessage(nil)},
+	{Method: "POST", Path: "/conversations/{id}/messages", Tag: "conversations", Summary: "Send a message in a conversation", Request: Partial(schemas.DirectMessageCreate{}, "conversation_id", "sender_id"), Data: (*models.DirectMessage)(nil)},
+	{Method: "POST", Path: "/conversations/{id}/read", Tag: "conversations", Summary: "Mark a conversation as read", Message: true},
+
+	// Notifications
+	{Method: "GET", Path: "/notifications", Tag: "notifications", Summary: "List notifications of the signed in user", Query: pageQuery, Data: []*models.Notification(nil), Fields: Object{"unread": 0}},
+	{Method: "GET", Path: "/notifications/unread", Tag: "notifications", Summary: "Count unread notifications", Fields: Object{"unread": 0}},
+	{Method: "POST", Path: "/notifications/read", Tag: "notifications", Summary: "Mark notifications as read", Request: schemas.NotificationRead{}, Message: true},
+	{Method: "GET", Path: "/notifications/settings", Tag: "notifications", Summary: "Get notification settings", Data: (*schemas.NotificationSettings)(nil)},
+	{Method: "PUT", Path: "/notifications/settings", Tag: "notifications", Summary: "Update notification settings, of a club when club_id is given", Request: schemas.NotificationSettingUpdate{}, Data: (*models.NotificationSetting)(nil)},
+
+	// Moderation
+	{Method: "POST", Path: "/reports", Tag: "moderation", Summary: "Report a message, comment, user or club", Request: schemas.ReportCreate{}, Data: (*models.Report)(nil)},
+
+	// Administration
+	{Method: "GET", Path: "/admin/users", Tag: "admin", Summary: "Search users", Query: Object{"q": "", "page": 0}, Data: []*schemas.AdminUser(nil)},
+	{Method: "GET", Path: "/admin/clubs", Tag: "admin", Summary: "Search clubs", Query: Object{"q": "", "page": 0}, Data: []*schemas.Club(nil)},
+	{Method: "GET", Path: "/admin/reports", Tag: "admin", Summary: "List reports", Query: Object{"status": "", "page": 0}, Data: []*models.Report(nil)},
+	{Method: "POST", Path: "/admin/reports/review", Tag: "admin", Summary: "Review a report", Request: schemas.ReportReview{}, Data: (*models.Report)(nil)},
+	{Method: "POST", Path: "/admin/content/hide", Tag: "admin", Summary: "Hide or unhide a message or comment", Request: Object{"target_type": "", "target_id": "", "hidden": false}, Message: true},
+	{Method: "POST", Path: "/admin/users/suspend", Tag: "admin", Summary: "Suspend a user", Request: Object{"user_id": "", "days": 0}, Message: true},
+	{Method: "POST", Path: "/admin/users/unsuspend", Tag: "admin", Summary: "Lift the suspension of a user", Request: userIDBody, Message: true},
+	{Method: "POST", Path: "/admin/users/role", Tag: "admin", Summary: "Set the platform role of a user", Request: Object{"user_id": "", "role": ""}, Message: true},
+	{Method: "POST", Path: "/admin/clubs/transfer", Tag: "admin", Summary: "Transfer a club to another host", Request: Object{"club_id": "", "user_id": ""}, Data: (*models.Club)(nil)},
+	{Method: "POST", Path: "/admin/clubs/delete", Tag: "admin", Summary: "Delete a club", Request: clubIDBody, Message: true},
+	{Method: "GET", Path: "/admin/audit", Tag: "admin", Summary: "List the audit log of every club", Query: Object{"club_id": "", "page": 0}, Data: []*models.AuditLog(nil)},
+}
@@ -0,0 +1,410 @@
+package openapi_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Krishap-s/keats-backend/api/server/servertest"
+	"github.com/Krishap-s/keats-backend/crud"
+	"github.com/Krishap-s/keats-backend/models"
+)
+
+// request is a request made to an operation of the API
+type request struct {
+	// token signs the request in, public operations are requested without one
+	token string
+	// path is the path of the operation with its parameters filled in, when it has any
+	path  string
+	query url.Values
+	body  interface{}
+}
+
+// operationCase creates the fixtures of a request to an operation
+type operationCase struct {
+	method string
+	path   string
+	// skip tells why the operation cannot be requested in tests
+	skip    string
+	prepare func(t *testing.T) request
+}
+
+// signedIn creates a user with role and signs them in
+func signedIn(t *testing.T, role string) (*models.User, string) {
+	user := servertest.User(t, role)
+	return user, servertest.Token(t, user)
+}
+
+// memberOf creates a club hosted by another user and signs in a member of it
+func memberOf(t *testing.T) (*models.User, string, *models.Club) {
+	host := servertest.User(t, models.RoleMember)
+	club := servertest.Club(t, host)
+	user, token := signedIn(t, models.RoleMember)
+	servertest.Join(t, club, user)
+	return user, token, club
+}
+
+// hosting creates a club hosted by a user who is signed in
+func hosting(t *testing.T) (*models.User, string, *models.Club) {
+	user, token := signedIn(t, models.RoleMember)
+	return user, token, servertest.Club(t, user)
+}
+
+// conversing creates a conversation between a user who is signed in and another
+func conversing(t *testing.T) (string, *models.Conversation) {
+	user, token := signedIn(t, models.RoleMember)
+	other := servertest.User(t, models.RoleMember)
+	return token, servertest.Conversation(t, other, user)
+}
+
+// reported creates a chat message reported by a member of its club
+func reported(t *testing.T) (*models.Club, *models.ChatMessage, *models.Report) {
+	author := servertest.User(t, models.RoleMember)
+	club := servertest.Club(t, author)
+	chatMessage := servertest.ChatMessage(t, club, author)
+	reporter := servertest.User(t, models.RoleMember)
+	servertest.Join(t, club, reporter)
+	return club, chatMessage, servertest.Report(t, reporter, chatMessage)
+}
+
+// staff signs in an administrator
+func staff(t *testing.T) string {
+	_, token := signedIn(t, models.RoleAdmin)
+	return token
+}
+
+func clubIDBody(club *models.Club) fiber.Map {
+	return fiber.Map{"club_id": club.ID}
+}
+
+// operationCases requests every operation of the API, in the order of
+// Operations
+var operationCases = []operationCase{
+	// Users
+	{method: "POST", path: "/user", skip: "signs in with a Firebase ID token"},
+	{method: "POST", path: "/user/email/code", prepare: func(t *testing.T) request {
+		return request{body: fiber.Map{"email": servertest.Email()}}
+	}},
+	{method: "POST", path: "/user/email/login", prepare: func(t *testing.T) request {
+		user := servertest.EmailUser(t)
+		servertest.SetRedis(t, "email_code_login_"+user.Email, "123456")
+		return request{body: fiber.Map{"email": user.Email, "code": "123456"}}
+	}},
+	{method: "GET", path: "/user", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token}
+	}},
+	{method: "PATCH", path: "/user", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token, body: fiber.Map{"username": "Servertest reader", "bio": "Reads in tests"}}
+	}},
+	{method: "DELETE", path: "/user", prepare: func(t *testing.T) request {
+		_, token, _ := memberOf(t)
+		return request{token: token, query: url.Values{"content": {"delete"}}}
+	}},
+	{method: "POST", path: "/user/updatephone", skip: "verifies the new phone number with a Firebase ID token"},
+	{method: "POST", path: "/user/updatephone/confirm", prepare: func(t *testing.T) request {
+		user, token := signedIn(t, models.RoleMember)
+		changeID := uuid.NewString()
+		servertest.SetRedis(t, "phone_change_"+user.ID.String()+"_"+changeID, servertest.PhoneNo())
+		return request{token: token, body: fiber.Map{"change_id": changeID}}
+	}},
+	{method: "GET", path: "/user/clubs", prepare: func(t *testing.T) request {
+		_, token, _ := memberOf(t)
+		return request{token: token, query: url.Values{"page": {"1"}}}
+	}},
+	{method: "GET", path: "/user/export", prepare: func(t *testing.T) request {
+		user, token, club := memberOf(t)
+		servertest.ChatMessage(t, club, user)
+		servertest.Comment(t, club, user)
+		return request{token: token}
+	}},
+	{method: "POST", path: "/user/email/verify/code", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token, body: fiber.Map{"email": servertest.Email()}}
+	}},
+	{method: "POST", path: "/user/email/verify", prepare: func(t *testing.T) request {
+		user, token := signedIn(t, models.RoleMember)
+		email := servertest.Email()
+		servertest.SetRedis(t, "email_code_verify_"+user.ID.String()+"_"+email, "123456")
+		return request{token: token, body: fiber.Map{"email": email, "code": "123456"}}
+	}},
+	{method: "GET", path: "/user/blocks", prepare: func(t *testing.T) request {
+		user, token := signedIn(t, models.RoleMember)
+		other := servertest.User(t, models.RoleMember)
+		if _, err := crud.BlockUser(user.ID.String(), other.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+		return request{token: token}
+	}},
+	{method: "POST", path: "/user/block", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		other := servertest.User(t, models.RoleMember)
+		return request{token: token, body: fiber.Map{"user_id": other.ID}}
+	}},
+	{method: "POST", path: "/user/unblock", prepare: func(t *testing.T) request {
+		user, token := signedIn(t, models.RoleMember)
+		other := servertest.User(t, models.RoleMember)
+		if _, err := crud.BlockUser(user.ID.String(), other.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+		return request{token: token, body: fiber.Map{"user_id": other.ID}}
+	}},
+	{method: "GET", path: "/user/handle", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		handle := "st_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+		return request{token: token, query: url.Values{"handle": {handle}}}
+	}},
+	{method: "POST", path: "/user/devices", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token, body: fiber.Map{"token": uuid.NewString(), "platform": "android"}}
+	}},
+	{method: "DELETE", path: "/user/devices", prepare: func(t *testing.T) request {
+		user, token := signedIn(t, models.RoleMember)
+		deviceToken := uuid.NewString()
+		if _, err := crud.RegisterDeviceToken(user.ID.String(), deviceToken, "android"); err != nil {
+			t.Fatal(err)
+		}
+		return request{token: token, body: fiber.Map{"token": deviceToken, "platform": "android"}}
+	}},
+	{method: "GET", path: "/user/{id}", prepare: func(t *testing.T) request {
+		_, token, club := memberOf(t)
+		return request{token: token, path: "/user/" + club.HostID.String()}
+	}},
+
+	// Clubs
+	{method: "GET", path: "/clubs", prepare: func(t *testing.T) request {
+		user, token, club := memberOf(t)
+		servertest.ChatMessage(t, club, user)
+		servertest.Comment(t, club, user)
+		return request{token: token, query: url.Values{"club_id": {club.ID.String()}}}
+	}},
+	{method: "GET", path: "/clubs/list", prepare: func(t *testing.T) request {
+		servertest.Club(t, servertest.User(t, models.RoleMember))
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token, query: url.Values{"page": {"1"}}}
+	}},
+	{method: "GET", path: "/clubs/discover", prepare: func(t *testing.T) request {
+		servertest.Club(t, servertest.User(t, models.RoleMember))
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token}
+	}},
+	{method: "GET", path: "/clubs/trending", prepare: func(t *testing.T) request {
+		token := rankedClub(t)
+		return request{token: token, query: url.Values{"page": {"1"}}}
+	}},
+	{method: "GET", path: "/clubs/recommended", prepare: func(t *testing.T) request {
+		token := rankedClub(t)
+		return request{token: token, query: url.Values{"page": {"1"}}}
+	}},
+	{method: "GET", path: "/clubs/categories", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token}
+	}},
+	{method: "GET", path: "/clubs/tags", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token, query: url.Values{"q": {models.Categories[0][:3]}}}
+	}},
+	{method: "GET", path: "/clubs/audit", prepare: func(t *testing.T) request {
+		user, token, club := hosting(t)
+		servertest.AuditLog(t, club, user)
+		return request{token: token, query: url.Values{"club_id": {club.ID.String()}, "page": {"1"}}}
+	}},
+	{method: "POST", path: "/clubs/create", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token, body: fiber.Map{
+			"clubname":    "Servertest club",
+			"book_title":  "Servertest",
+			"book_author": "Keats",
+			"tags":        []string{models.Categories[0]},
+		}}
+	}},
+	{method: "POST", path: "/clubs/join", prepare: func(t *testing.T) request {
+		club := servertest.Club(t, servertest.User(t, models.RoleMember))
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token, body: clubIDBody(club)}
+	}},
+	{method: "PATCH", path: "/clubs/update", prepare: func(t *testing.T) request {
+		_, token, club := hosting(t)
+		return request{token: token, body: fiber.Map{"id": club.ID, "clubname": "Renamed club", "page_no": 2}}
+	}},
+	{method: "POST", path: "/clubs/toggleprivate", prepare: func(t *testing.T) request {
+		_, token, club := hosting(t)
+		return request{token: token, body: clubIDBody(club)}
+	}},
+	{method: "POST", path: "/clubs/togglesync", prepare: func(t *testing.T) request {
+		_, token, club := hosting(t)
+		return request{token: token, body: clubIDBody(club)}
+	}},
+	{method: "POST", path: "/clubs/togglearchive", prepare: func(t *testing.T) request {
+		_, token, club := hosting(t)
+		return request{token: token, body: clubIDBody(club)}
+	}},
+	{method: "POST", path: "/clubs/delete", prepare: func(t *testing.T) request {
+		_, token, club := hosting(t)
+		return request{token: token, body: clubIDBody(club)}
+	}},
+	{method: "POST", path: "/clubs/kickuser", prepare: func(t *testing.T) request {
+		_, token, club := hosting(t)
+		member := servertest.User(t, models.RoleMember)
+		servertest.Join(t, club, member)
+		return request{token: token, body: fiber.Map{"club_id": club.ID, "user_id": member.ID}}
+	}},
+	{method: "POST", path: "/clubs/leave", prepare: func(t *testing.T) request {
+		_, token, club := memberOf(t)
+		return request{token: token, body: clubIDBody(club)}
+	}},
+	{method: "POST", path: "/clubs/mute", prepare: func(t *testing.T) request {
+		_, token, club := memberOf(t)
+		return request{token: token, body: fiber.Map{"club_id": club.ID, "muted": true}}
+	}},
+	{method: "POST", path: "/clubs/filter", prepare: func(t *testing.T) request {
+		_, token, club := hosting(t)
+		return request{token: token, body: fiber.Map{"club_id": club.ID, "strictness": "strict"}}
+	}},
+
+	// Conversations
+	{method: "GET", path: "/conversations", prepare: func(t *testing.T) request {
+		token, _ := conversing(t)
+		return request{token: token}
+	}},
+	{method: "POST", path: "/conversations", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		other := servertest.User(t, models.RoleMember)
+		return request{token: token, body: fiber.Map{"user_id": other.ID}}
+	}},
+	{method: "GET", path: "/conversations/{id}/messages", prepare: func(t *testing.T) request {
+		token, conversation := conversing(t)
+		return request{token: token, path: "/conversations/" + conversation.ID.String() + "/messages"}
+	}},
+	{method: "POST", path: "/conversations/{id}/messages", prepare: func(t *testing.T) request {
+		token, conversation := conversing(t)
+		return request{token: token, path: "/conversations/" + conversation.ID.String() + "/messages",
+			body: fiber.Map{"message": "Servertest reply"}}
+	}},
+	{method: "POST", path: "/conversations/{id}/read", prepare: func(t *testing.T) request {
+		token, conversation := conversing(t)
+		return request{token: token, path: "/conversations/" + conversation.ID.String() + "/read"}
+	}},
+
+	// Notifications
+	{method: "GET", path: "/notifications", prepare: func(t *testing.T) request {
+		token, _ := notified(t)
+		return request{token: token, query: url.Values{"page": {"1"}}}
+	}},
+	{method: "GET", path: "/notifications/unread", prepare: func(t *testing.T) request {
+		token, _ := notified(t)
+		return request{token: token}
+	}},
+	{method: "POST", path: "/notifications/read", prepare: func(t *testing.T) request {
+		token, notification := notified(t)
+		return request{token: token, body: fiber.Map{"ids": []string{notification.ID.String()}}}
+	}},
+	{method: "GET", path: "/notifications/settings", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token}
+	}},
+	{method: "PUT", path: "/notifications/settings", prepare: func(t *testing.T) request {
+		_, token := signedIn(t, models.RoleMember)
+		return request{token: token, body: fiber.Map{"chat": false, "quiet_start": "22:00", "quiet_end": "07:00"}}
+	}},
+
+	// Moderation
+	{method: "POST", path: "/reports", prepare: func(t *testing.T) request {
+		_, token, club := memberOf(t)
+		host := &models.User{ID: club.HostID}
+		chatMessage := servertest.ChatMessage(t, club, host)
+		return request{token: token, body: fiber.Map{
+			"target_type": models.ReportChatMessage,
+			"target_id":   chatMessage.ID,
+			"reason":      "Servertest report",
+		}}
+	}},
+
+	// Administration
+	{method: "GET", path: "/admin/users", prepare: func(t *testing.T) request {
+		user := servertest.User(t, models.RoleMember)
+		return request{token: staff(t), query: url.Values{"q": {user.Handle}, "page": {"1"}}}
+	}},
+	{method: "GET", path: "/admin/clubs", prepare: func(t *testing.T) request {
+		servertest.Club(t, servertest.User(t, models.RoleMember))
+		return request{token: staff(t), query: url.Values{"q": {"Servertest"}, "page": {"1"}}}
+	}},
+	{method: "GET", path: "/admin/reports", prepare: func(t *testing.T) request {
+		reported(t)
+		return request{token: staff(t), query: url.Values{"status": {models.ReportOpen}, "page": {"1"}}}
+	}},
+	{method: "POST", path: "/admin/reports/review", prepare: func(t *testing.T) request {
+		_, _, report := reported(t)
+		return request{token: staff(t), body: fiber.Map{
+			"report_id":  report.ID,
+			"action":     "hide",
+			"resolution": "Servertest resolution",
+		}}
+	}},
+	{method: "POST", path: "/admin/content/hide", prepare: func(t *testing.T) request {
+		_, chatMessage, _ := reported(t)
+		return request{token: staff(t), body: fiber.Map{
+			"target_type": models.ReportChatMessage,
+			"target_id":   chatMessage.ID,
+			"hidden":      true,
+		}}
+	}},
+	{method: "POST", path: "/admin/users/suspend", prepare: func(t *testing.T) request {
+		user := servertest.User(t, models.RoleMember)
+		return request{token: staff(t), body: fiber.Map{"user_id": user.ID, "days": 1}}
+	}},
+	{method: "POST", path: "/admin/users/unsuspend", prepare: func(t *testing.T) request {
+		admin, token := signedIn(t, models.RoleAdmin)
+		user := servertest.User(t, models.RoleMember)
+		until := time.Now().AddDate(0, 0, 1)
+		if err := crud.SetUserSuspension(admin.ID.String(), user.ID.String(), &until, nil); err != nil {
+			t.Fatal(err)
+		}
+		return request{token: token, body: fiber.Map{"user_id": user.ID}}
+	}},
+	{method: "POST", path: "/admin/users/role", prepare: func(t *testing.T) request {
+		user := servertest.User(t, models.RoleMember)
+		return request{token: staff(t), body: fiber.Map{"user_id": user.ID, "role": models.RoleModerator}}
+	}},
+	{method: "POST", path: "/admin/clubs/transfer", prepare: func(t *testing.T) request {
+		club := servertest.Club(t, servertest.User(t, models.RoleMember))
+		member := servertest.User(t, models.RoleMember)
+		servertest.Join(t, club, member)
+		return request{token: staff(t), body: fiber.Map{"club_id": club.ID, "user_id": member.ID}}
+	}},
+	{method: "POST", path: "/admin/clubs/delete", prepare: func(t *testing.T) request {
+		club := servertest.Club(t, servertest.User(t, models.RoleMember))
+		return request{token: staff(t), body: clubIDBody(club)}
+	}},
+	{method: "GET", path: "/admin/audit", prepare: func(t *testing.T) request {
+		host := servertest.User(t, models.RoleMember)
+		club := servertest.Club(t, host)
+		servertest.AuditLog(t, club, host)
+		return request{token: staff(t), query: url.Values{"club_id": {club.ID.String()}, "page": {"1"}}}
+	}},
+}
+
+// rankedClub creates a club with recent activity, ranks clubs and signs in a
+// user who has not joined it
+func rankedClub(t *testing.T) string {
+	host := servertest.User(t, models.RoleMember)
+	club := servertest.Club(t, host)
+	servertest.ChatMessage(t, club, host)
+	if err := crud.RankClubs(time.Now().AddDate(0, 0, -7)); err != nil {
+		t.Fatal(err)
+	}
+	_, token := signedIn(t, models.RoleMember)
+	return token
+}
+
+// notified signs in a member of a club notified of an update to it
+func notified(t *testing.T) (string, *models.Notification) {
+	user, token, club := memberOf(t)
+	host := &models.User{ID: club.HostID}
+	return token, servertest.Notification(t, user, host, club)
+}
@@ -0,0 +1,275 @@
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is a schema object of OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Object describes a JSON object the handlers build by hand as a fiber.Map.
+// Each key maps to a value of the Go type sent under it, for example
+// Object{"token": "", "clubs": []*schemas.Club(nil)}, and every key is required.
+type Object map[string]interface{}
+
+// partial is a struct described without some of its fields
+type partial struct {
+	value interface{}
+	omit  []string
+}
+
+// Partial describes the struct v without the fields named in omit, for
+// requests whose handlers fill those fields in themselves
+func Partial(v interface{}, omit ...string) interface{} {
+	return partial{value: v, omit: omit}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// Generator builds the schemas of Go types and collects the named structs it
+// meets as components, so each is described once and referenced elsewhere
+type Generator struct {
+	components map[string]*Schema
+}
+
+func NewGenerator() *Generator {
+	return &Generator{components: map[string]*Schema{}}
+}
+
+// Components returns the schemas of the named structs met so far by their component name
+func (g *Generator) Components() map[string]*Schema {
+	return g.components
+}
+
+// componentName names the component of a struct after its package, e.g. models.User
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	return pkg[strings.LastIndex(pkg, "/")+1:] + "." + t.Name()
+}
+
+// SchemaOf returns the schema of the Go value v. Fields of request bodies are
+// constrained by their validate tags and only those tagged required are
+// required, while every field of a response is required unless it is omitempty.
+// A named struct is described the way it is first met.
+func (g *Generator) SchemaOf(v interface{}, request bool) *Schema {
+	switch value := v.(type) {
+	case Object:
+		return g.objectSchema(value, request)
+	case partial:
+		// Described inline since the component would list the omitted fields
+		s := g.structSchema(reflect.TypeOf(value.value), request)
+		for _, name := range value.omit {
+			delete(s.Properties, name)
+			s.Required = without(s.Required, name)
+		}
+		return s
+	}
+	return g.typeSchema(reflect.TypeOf(v), request)
+}
+
+func (g *Generator) objectSchema(object Object, request bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for key, value := range object {
+		s.Properties[key] = g.SchemaOf(value, request)
+		s.Required = append(s.Required, key)
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+func (g *Generator) typeSchema(t reflect.Type, request bool) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := g.typeSchema(t.Elem(), request)
+		if s.Ref != "" {
+			// Siblings of $ref are ignored, so nullable references are wrapped
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// Nil slices are encoded as null
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem(), request), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem(), request), Nullable: true}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, request)
+		}
+		name := componentName(t)
+		if _, ok := g.components[name]; !ok {
+			// Reserve the name first so recursive types end
+			g.components[name] = nil
+			g.components[name] = g.structSchema(t, request)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	panic("openapi: cannot describe " + t.String())
+}
+
+func (g *Generator) structSchema(t reflect.Type, request bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(s, t, request)
+	sort.Strings(s.Required)
+	return s
+}
+
+// addFields adds the fields of t to s the way encoding/json sees them, with
+// the fields of embedded structs promoted
+func (g *Generator) addFields(s *Schema, t reflect.Type, request bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag == "" {
+			g.addFields(s, field.Type, request)
+			continue
+		}
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "" {
+			name = field.Name
+		}
+		property := g.typeSchema(field.Type, request)
+		if request {
+			validateTag := field.Tag.Get("validate")
+			constrain(property, field.Type, validateTag)
+			if hasRule(validateTag, "required") {
+				s.Required = append(s.Required, name)
+			}
+		} else if !hasOption(parts[1:], "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = property
+	}
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRule(validateTag string, rule string) bool {
+	for _, part := range strings.Split(validateTag, ",") {
+		if strings.SplitN(part, "=", 2)[0] == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// constrain describes the rules of a validate tag in the schema of the field they apply to
+func constrain(s *Schema, t reflect.Type, validateTag string) {
+	if validateTag == "" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, part := range strings.Split(validateTag, ",") {
+		rule, param := part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			rule, param = part[:i], part[i+1:]
+		}
+		switch rule {
+		case "min", "max":
+			setLimit(s, t, rule, param)
+		case "url":
+			s.Format = "uri"
+		case "email":
+			s.Format = "email"
+		case "e164":
+			s.Pattern = `^\+[1-9][0-9]{1,14}$`
+		case "uuid":
+			s.Format = "uuid"
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "clock":
+			s.Pattern = `^[0-9]{2}:[0-9]{2}$`
+		case "timezone":
+			s.Description = "IANA timezone, e.g. Asia/Kolkata"
+		}
+	}
+}
+
+// setLimit sets the bound of a min or max rule, which counts characters of
+// strings, items of slices and the value of numbers
+func setLimit(s *Schema, t reflect.Type, rule string, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic("openapi: invalid limit " + param)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		length := int(n)
+		if rule == "min" {
+			s.MinLength = &length
+		} else {
+			s.MaxLength = &length
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		items := int(n)
+		if rule == "min" {
+			s.MinItems = &items
+		} else {
+			s.MaxItems = &items
+		}
+	default:
+		if rule == "min" {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
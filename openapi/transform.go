@@ -0,0 +1,38 @@
+package openapi
+
+import "strings"
+
+// Transform walks the decoded JSON value along the schema s, with references
+// resolved against components, and calls fn on every object described by a
+// component with the name of the component. Objects nested in an object are
+// passed to fn before it.
+func Transform(s *Schema, components map[string]*Schema, value interface{}, fn func(component string, object map[string]interface{})) {
+	if s == nil || value == nil {
+		return
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		Transform(components[name], components, value, fn)
+		if object, ok := value.(map[string]interface{}); ok {
+			fn(name, object)
+		}
+		return
+	}
+	for _, sub := range s.AllOf {
+		Transform(sub, components, value, fn)
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, property := range v {
+			propertySchema, ok := s.Properties[name]
+			if !ok {
+				propertySchema = s.AdditionalProperties
+			}
+			Transform(propertySchema, components, property, fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			Transform(s.Items, components, item, fn)
+		}
+	}
+}
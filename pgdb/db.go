@@ -3,6 +3,7 @@ package pgdb
 import (
 	"context"
 	"log"
+	"strings"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/go-pg/pg/v10/orm"
@@ -30,6 +31,40 @@ func GetDB() *pg.DB {
 	return db
 }
 
+// seedCategories inserts the curated categories as tags
+func seedCategories(ctx context.Context) error {
+	for _, category := range models.Categories {
+		tag := &models.Tag{
+			Name:    category,
+			Curated: true,
+		}
+		_, err := GetDB().ModelContext(ctx, tag).
+			OnConflict("(name) DO UPDATE").
+			Set("curated = EXCLUDED.curated").
+			Insert()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedAdmins gives the admin role to the users listed in ADMIN_USER_IDS so
+// that the first administrators do not have to be set up by hand
+func seedAdmins(ctx context.Context) error {
+	ids := strings.FieldsFunc(viper.GetString("ADMIN_USER_IDS"), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := GetDB().ModelContext(ctx, (*models.User)(nil)).
+		Set("role = ?", models.RoleAdmin).
+		WhereIn("id::text IN (?)", ids).
+		Update()
+	return err
+}
+
 // Migrate runs database migrations
 func Migrate() error {
 	models := []interface{}{
@@ -38,6 +73,58 @@ func Migrate() error {
 		(*models.Comment)(nil),
 		(*models.ChatMessage)(nil),
 		(*models.ClubUser)(nil),
+		(*models.ClubWaitlist)(nil),
+		(*models.Tag)(nil),
+		(*models.ClubTag)(nil),
+		(*models.ClubScore)(nil),
+		(*models.PhoneNoHistory)(nil),
+		(*models.UserBlock)(nil),
+		(*models.Conversation)(nil),
+		(*models.DirectMessage)(nil),
+		(*models.Notification)(nil),
+		(*models.DeviceToken)(nil),
+		(*models.NotificationSetting)(nil),
+		(*models.Report)(nil),
+		(*models.AuditLog)(nil),
+	}
+
+	// Columns and indexes added to tables after they were first created
+	alterations := []string{
+		"ALTER TABLE clubs ADD COLUMN IF NOT EXISTS max_members bigint NOT NULL DEFAULT 0",
+		"ALTER TABLE clubs ADD COLUMN IF NOT EXISTS archived boolean DEFAULT false",
+		"ALTER TABLE clubs ADD COLUMN IF NOT EXISTS book_title text",
+		"ALTER TABLE clubs ADD COLUMN IF NOT EXISTS book_author text",
+		"ALTER TABLE clubs ADD COLUMN IF NOT EXISTS genre text",
+		"ALTER TABLE clubs ADD COLUMN IF NOT EXISTS language text",
+		"ALTER TABLE clubs ADD COLUMN IF NOT EXISTS filter_strictness text NOT NULL DEFAULT 'standard'",
+		// Clubs, memberships and comments predating time_created are backfilled
+		// to the epoch, so they do not count as recent activity when ranking clubs
+		"ALTER TABLE clubs ADD COLUMN IF NOT EXISTS time_created timestamptz NOT NULL DEFAULT 'epoch'",
+		"ALTER TABLE clubs ALTER COLUMN time_created SET DEFAULT now()",
+		"ALTER TABLE club_users ADD COLUMN IF NOT EXISTS time_created timestamptz NOT NULL DEFAULT 'epoch'",
+		"ALTER TABLE club_users ALTER COLUMN time_created SET DEFAULT now()",
+		"ALTER TABLE club_users ADD COLUMN IF NOT EXISTS muted boolean NOT NULL DEFAULT false",
+		"ALTER TABLE comments ADD COLUMN IF NOT EXISTS time_created timestamptz NOT NULL DEFAULT 'epoch'",
+		"ALTER TABLE comments ALTER COLUMN time_created SET DEFAULT now()",
+		"ALTER TABLE comments ADD COLUMN IF NOT EXISTS hidden boolean NOT NULL DEFAULT false",
+		"ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS hidden boolean NOT NULL DEFAULT false",
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS handle text UNIQUE",
+		"UPDATE users SET handle = 'reader_' || substr(replace(id::text, '-', ''), 1, 12) WHERE handle IS NULL",
+		"ALTER TABLE users ALTER COLUMN phone_no DROP NOT NULL",
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified boolean DEFAULT false",
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version bigint DEFAULT 0",
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'member'",
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until timestamptz",
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS locale text",
+		"CREATE UNIQUE INDEX IF NOT EXISTS users_verified_email_idx ON users (lower(email)) WHERE email_verified",
+		"CREATE INDEX IF NOT EXISTS direct_messages_conversation_idx ON direct_messages (conversation_id, time_created)",
+		"CREATE INDEX IF NOT EXISTS audit_logs_club_idx ON audit_logs (club_id, time_created)",
+		// The audit log is append-only, rows can neither be changed nor removed
+		"CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'audit_logs is append-only'; END; $$ LANGUAGE plpgsql",
+		"DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs",
+		"CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs FOR EACH ROW EXECUTE PROCEDURE audit_logs_append_only()",
+		"CREATE INDEX IF NOT EXISTS notifications_user_idx ON notifications (user_id, time_created)",
+		"CREATE INDEX IF NOT EXISTS clubs_search_idx ON clubs USING GIN (to_tsvector('simple', coalesce(club_name, '') || ' ' || coalesce(book_title, '') || ' ' || coalesce(book_author, '')))",
 	}
 
 	ctx := context.Background()
@@ -62,5 +149,19 @@ func Migrate() error {
 		}
 	}
 
+	for _, alteration := range alterations {
+		if _, err := GetDB().ExecContext(ctx, alteration); err != nil {
+			return err
+		}
+	}
+
+	if err := seedCategories(ctx); err != nil {
+		return err
+	}
+
+	if err := seedAdmins(ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,121 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/spf13/viper"
+
+	"github.com/Krishap-s/keats-backend/firebaseclient"
+)
+
+// maxTokensPerSend is the most device tokens FCM accepts in a single multicast
+const maxTokensPerSend = 500
+
+// Message represents a push notification to be shown on devices
+type Message struct {
+	Title string
+	Body  string
+	// Messages sharing a collapse key replace each other on the device
+	CollapseKey string
+	Data        map[string]string
+}
+
+// PushSender sends push notifications to device tokens and returns the tokens
+// that are no longer registered so they can be forgotten
+type PushSender interface {
+	Send(tokens []string, message *Message) ([]string, error)
+}
+
+// FCMSender sends push notifications through Firebase Cloud Messaging
+type FCMSender struct{}
+
+// Send sends the push notification to the devices in batches FCM accepts
+func (s *FCMSender) Send(tokens []string, message *Message) ([]string, error) {
+	client, err := firebaseclient.GetMessaging()
+	if err != nil {
+		return nil, err
+	}
+	var unregistered []string
+	for start := 0; start < len(tokens); start += maxTokensPerSend {
+		end := start + maxTokensPerSend
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		batch := tokens[start:end]
+		var res *messaging.BatchResponse
+		res, err = client.SendMulticast(context.Background(), &messaging.MulticastMessage{
+			Tokens: batch,
+			Data:   message.Data,
+			Notification: &messaging.Notification{
+				Title: message.Title,
+				Body:  message.Body,
+			},
+			Android: &messaging.AndroidConfig{
+				CollapseKey: message.CollapseKey,
+			},
+			APNS: &messaging.APNSConfig{
+				Headers: map[string]string{
+					"apns-collapse-id": message.CollapseKey,
+				},
+			},
+		})
+		if err != nil {
+			return unregistered, err
+		}
+		for i, r := range res.Responses {
+			if !r.Success && messaging.IsRegistrationTokenNotRegistered(r.Error) {
+				unregistered = append(unregistered, batch[i])
+			}
+		}
+	}
+	return unregistered, nil
+}
+
+// FileSender appends push notifications to a file instead of sending them,
+// for development and for deployments without Firebase credentials
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send appends the push notification to the file
+func (s *FileSender) Send(tokens []string, message *Message) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nCollapse-Key: %s\nTitle: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC1123Z), strings.Join(tokens, ", "), message.CollapseKey, message.Title, message.Body)
+	return nil, err
+}
+
+var sender PushSender = nil
+
+// GetSender returns a singleton reference to the push sender selected by PUSH_SENDER
+func GetSender() PushSender {
+	if sender != nil {
+		return sender
+	}
+	switch viper.GetString("PUSH_SENDER") {
+	case "fcm":
+		sender = &FCMSender{}
+	default:
+		path := viper.GetString("PUSH_LOG_FILE")
+		if path == "" {
+			path = "push.log"
+		}
+		sender = &FileSender{
+			Path: path,
+		}
+	}
+	return sender
+}
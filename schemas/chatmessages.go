@@ -4,9 +4,9 @@ import "time"
 
 // ChatMessageCreate represents a chat message to be created
 type ChatMessageCreate struct {
-	ClubID  string `json:"club_id"`
-	UserID  string `json:"user_id"`
-	Message string `json:"message"`
+	ClubID  string `json:"club_id" validate:"required,uuid"`
+	UserID  string `json:"user_id" validate:"required,uuid"`
+	Message string `json:"message" validate:"required,max=150"`
 	Likes   int    `json:"likes"`
 }
 
@@ -18,4 +18,5 @@ type ChatMessage struct {
 	Message     string    `json:"message"`
 	Likes       int       `json:"likes"`
 	TimeCreated time.Time `json:"time_created"`
+	Hidden      bool      `json:"-"`
 }
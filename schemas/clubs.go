@@ -1,37 +1,95 @@
 package schemas
 
+import "time"
+
 // ClubCreate represents a room to be created
 type ClubCreate struct {
-	ID       string `json:"id" form:"id"`
-	ClubName string `json:"clubname" form:"clubname"`
-	ClubPic  string `json:"club_pic" form:"club_pic"`
-	FileURL  string `json:"file_url" form:"file_url"`
-	PageNo   int    `json:"page_no" form:"page_no"`
-	Private  bool   `json:"private" form:"private"`
-	PageSync bool   `json:"page_sync" form:"page_sync"`
-	HostID   string `json:"host_id" form:"host_id"`
+	ID         string   `json:"id" form:"id"`
+	ClubName   string   `json:"clubname" form:"clubname" validate:"required,max=30"`
+	ClubPic    string   `json:"club_pic" form:"club_pic" validate:"url,max=100"`
+	FileURL    string   `json:"file_url" form:"file_url" validate:"url"`
+	PageNo     int      `json:"page_no" form:"page_no" validate:"min=0"`
+	Private    bool     `json:"private" form:"private"`
+	PageSync   bool     `json:"page_sync" form:"page_sync"`
+	HostID     string   `json:"host_id" form:"host_id"`
+	MaxMembers int      `json:"max_members" form:"max_members" validate:"min=0"`
+	BookTitle  string   `json:"book_title" form:"book_title" validate:"max=100"`
+	BookAuthor string   `json:"book_author" form:"book_author" validate:"max=60"`
+	Genre      string   `json:"genre" form:"genre" validate:"max=30"`
+	Language   string   `json:"language" form:"language" validate:"max=30"`
+	Tags       []string `json:"tags" form:"tags"`
 }
 
 // ClubUpdate represents a room to be updated
 type ClubUpdate struct {
-	ID       string `json:"id" form:"id"`
-	ClubName string `json:"clubname" form:"clubname"`
-	ClubPic  string `json:"club_pic"`
-	FileURL  string `json:"file_url"`
-	PageNo   int    `json:"page_no" form:"page_no"`
-	HostID   string `json:"host_id" form:"host_id"`
+	ID         string   `json:"id" form:"id" validate:"required,uuid"`
+	ClubName   string   `json:"clubname" form:"clubname" validate:"max=30"`
+	ClubPic    string   `json:"club_pic" validate:"url,max=100"`
+	FileURL    string   `json:"file_url" validate:"url"`
+	PageNo     int      `json:"page_no" form:"page_no" validate:"min=0"`
+	HostID     string   `json:"host_id" form:"host_id"`
+	MaxMembers *int     `json:"max_members" form:"max_members" validate:"min=0"`
+	BookTitle  string   `json:"book_title" form:"book_title" validate:"max=100"`
+	BookAuthor string   `json:"book_author" form:"book_author" validate:"max=60"`
+	Genre      string   `json:"genre" form:"genre" validate:"max=30"`
+	Language   string   `json:"language" form:"language" validate:"max=30"`
+	Tags       []string `json:"tags" form:"tags"`
 }
 
 // Club represents a room to be returned as a response
 type Club struct {
-	ID             string `json:"id"`
-	ClubName       string `json:"clubname"`
-	ClubPic        string `json:"club_pic"`
-	FileURL        string `json:"file_url"`
-	PageNo         int    `json:"page_no"`
-	Private        bool   `json:"private"`
-	PageSync       bool   `json:"page_sync"`
-	HostID         string `json:"host_id"`
-	HostName       string `json:"host_name"`
-	HostProfilePic string `json:"host_profile_pic"`
+	ID               string   `json:"id"`
+	ClubName         string   `json:"clubname"`
+	ClubPic          string   `json:"club_pic"`
+	FileURL          string   `json:"file_url"`
+	PageNo           int      `json:"page_no"`
+	Private          bool     `json:"private"`
+	PageSync         bool     `json:"page_sync"`
+	HostID           string   `json:"host_id"`
+	HostName         string   `json:"host_name"`
+	HostProfilePic   string   `json:"host_profile_pic"`
+	MaxMembers       int      `json:"max_members"`
+	Archived         bool     `json:"archived"`
+	BookTitle        string   `json:"book_title"`
+	BookAuthor       string   `json:"book_author"`
+	Genre            string   `json:"genre"`
+	Language         string   `json:"language"`
+	Tags             []string `pg:",array" json:"tags"`
+	FilterStrictness string   `json:"filter_strictness"`
+}
+
+// ClubDiscovery represents a public club returned by the discovery API
+type ClubDiscovery struct {
+	Club
+	MemberCount  int       `json:"member_count"`
+	LastActivity time.Time `json:"last_activity"`
+	TimeCreated  time.Time `json:"time_created"`
+}
+
+// ClubSearch represents the filters, ordering and cursor of a discovery query
+type ClubSearch struct {
+	Query    string `query:"q" validate:"max=100"`
+	Genre    string `query:"genre" validate:"max=30"`
+	Language string `query:"language" validate:"max=30"`
+	Tag      string `query:"tag" validate:"max=30"`
+	Sort     string `query:"sort" validate:"oneof=recent activity members"`
+	Cursor   string `query:"cursor"`
+}
+
+// ClubPage represents a page of discovered clubs
+type ClubPage struct {
+	Clubs      []*ClubDiscovery `json:"clubs"`
+	NextCursor string           `json:"next_cursor"`
+	Total      int              `json:"total"`
+}
+
+// ClubRecommendation represents a club recommended to a user along with why it was picked
+type ClubRecommendation struct {
+	Club
+	Score    float64  `json:"score"`
+	Messages int      `json:"-"`
+	Comments int      `json:"-"`
+	Joins    int      `json:"-"`
+	Overlap  int      `json:"overlap"`
+	Reasons  []string `pg:"-" json:"reasons"`
 }
@@ -1,23 +1,28 @@
 package schemas
 
+import "time"
+
 // CommentCreate represents a comment to be created
 type CommentCreate struct {
 	ID       string `json:"id"`
-	ClubID   string `json:"club_id"`
-	ParentID string `json:"parent_id"`
-	UserID   string `json:"user_id"`
-	PageNo   int    `json:"page_no"`
-	Message  string `json:"message"`
+	ClubID   string `json:"club_id" validate:"required,uuid"`
+	ParentID string `json:"parent_id" validate:"required,uuid"`
+	UserID   string `json:"user_id" validate:"required,uuid"`
+	PageNo   int    `json:"page_no" validate:"required,min=1"`
+	Message  string `json:"message" validate:"required,max=150"`
 	Likes    int    `json:"likes"`
 }
 
 // Comment represents a comment to be returned as a response
 type Comment struct {
-	ID       string `json:"id"`
-	ClubID   string `json:"club_id"`
-	ParentID string `json:"parent_id"`
-	UserID   string `json:"user_id"`
-	PageNo   int    `json:"page_no"`
-	Message  string `json:"message"`
-	Likes    int    `json:"likes"`
+	ID          string    `json:"id"`
+	ClubID      string    `json:"club_id"`
+	ParentID    string    `json:"parent_id"`
+	UserID      string    `json:"user_id"`
+	PageNo      int       `json:"page_no"`
+	Message     string    `json:"message"`
+	Likes       int       `json:"likes"`
+	TimeCreated time.Time `json:"time_created"`
+	Blocked     bool      `json:"blocked"`
+	Hidden      bool      `json:"hidden"`
 }
@@ -0,0 +1,30 @@
+package schemas
+
+import "time"
+
+// DirectMessageCreate represents a direct message to be created
+type DirectMessageCreate struct {
+	ConversationID string `json:"conversation_id" validate:"required,uuid"`
+	SenderID       string `json:"sender_id" validate:"required,uuid"`
+	Message        string `json:"message" validate:"required,max=150"`
+}
+
+// DirectMessage represents a direct message to be returned as a response
+type DirectMessage struct {
+	ID             string     `json:"id"`
+	ConversationID string     `json:"conversation_id"`
+	SenderID       string     `json:"sender_id"`
+	RecipientID    string     `json:"recipient_id"`
+	Message        string     `json:"message"`
+	TimeCreated    time.Time  `json:"time_created"`
+	TimeRead       *time.Time `json:"time_read"`
+}
+
+// Conversation represents a conversation to be returned as a response
+type Conversation struct {
+	ID          string         `json:"id"`
+	User        *PublicUser    `pg:"-" json:"user"`
+	UserID      string         `json:"-"`
+	LastMessage *DirectMessage `pg:"-" json:"last_message"`
+	Unread      int            `json:"unread"`
+}
@@ -0,0 +1,39 @@
+package schemas
+
+// NotificationRead represents notifications to be marked as read, all of them if IDs is empty
+type NotificationRead struct {
+	IDs []string `json:"ids"`
+}
+
+// NotificationSettingUpdate represents notification preferences to be set,
+// for a single club when ClubID is set and as defaults otherwise
+type NotificationSettingUpdate struct {
+	ClubID      string `json:"club_id" validate:"uuid"`
+	Mentions    *bool  `json:"mentions"`
+	Replies     *bool  `json:"replies"`
+	Chat        *bool  `json:"chat"`
+	PageSync    *bool  `json:"page_sync"`
+	ClubUpdates *bool  `json:"club_updates"`
+	QuietStart  string `json:"quiet_start" validate:"clock"`
+	QuietEnd    string `json:"quiet_end" validate:"clock"`
+	Timezone    string `json:"timezone" validate:"timezone"`
+}
+
+// NotificationSetting represents notification preferences to be returned as a response
+type NotificationSetting struct {
+	ClubID      string `json:"club_id"`
+	Mentions    *bool  `json:"mentions"`
+	Replies     *bool  `json:"replies"`
+	Chat        *bool  `json:"chat"`
+	PageSync    *bool  `json:"page_sync"`
+	ClubUpdates *bool  `json:"club_updates"`
+	QuietStart  string `json:"quiet_start"`
+	QuietEnd    string `json:"quiet_end"`
+	Timezone    string `json:"timezone"`
+}
+
+// NotificationSettings represents the default notification preferences of a user and their overrides per club
+type NotificationSettings struct {
+	Default *NotificationSetting   `json:"default"`
+	Clubs   []*NotificationSetting `json:"clubs"`
+}
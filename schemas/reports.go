@@ -0,0 +1,17 @@
+package schemas
+
+// ReportCreate represents a report to be filed into the moderation queue
+type ReportCreate struct {
+	TargetType string `json:"target_type" validate:"required,oneof=chatmessage comment user club"`
+	TargetID   string `json:"target_id" validate:"required,uuid"`
+	Reason     string `json:"reason" validate:"required,max=500"`
+}
+
+// ReportReview represents a moderator settling a report, Action is one of
+// resolve, dismiss, hide or suspend
+type ReportReview struct {
+	ReportID    string `json:"report_id" validate:"required,uuid"`
+	Action      string `json:"action" validate:"required,oneof=resolve dismiss hide suspend"`
+	Resolution  string `json:"resolution" validate:"max=500"`
+	SuspendDays int    `json:"suspend_days" validate:"min=0"`
+}
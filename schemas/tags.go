@@ -0,0 +1,8 @@
+package schemas
+
+// Tag represents a tag to be returned as a response
+type Tag struct {
+	Name      string `json:"name"`
+	Curated   bool   `json:"curated"`
+	ClubCount int    `json:"club_count"`
+}
@@ -1,41 +1,120 @@
 package schemas
 
+import "time"
+
 // UserCreate represents a user to be created
 type UserCreate struct {
 	ID         string `json:"id"`
-	Username   string `json:"username"`
-	PhoneNo    string `json:"phone_number"`
-	ProfilePic string `json:"profile_pic"`
-	Email      string `json:"email"`
-	Bio        string `json:"bio"`
+	Username   string `json:"username" validate:"max=30"`
+	Handle     string `json:"handle"`
+	PhoneNo    string `json:"phone_number" validate:"e164"`
+	ProfilePic string `json:"profile_pic" validate:"url,max=100"`
+	Email      string `json:"email" validate:"max=50,email"`
+	Bio        string `json:"bio" validate:"max=100"`
 }
 
 // UserUpdate represents a user to be updated
 type UserUpdate struct {
-	ID         string `json:"id"`
-	Username   string `json:"username"`
-	PhoneNo    string `json:"phone_number"`
-	ProfilePic string `json:"profile_pic"`
-	Email      string `json:"email"`
-	Bio        string `json:"bio"`
+	ID         string `json:"id" validate:"required,uuid"`
+	Username   string `json:"username" validate:"max=30"`
+	Handle     string `json:"handle" validate:"max=20"`
+	PhoneNo    string `json:"phone_number" validate:"e164"`
+	ProfilePic string `json:"profile_pic" validate:"url,max=100"`
+	Email      string `json:"email" validate:"max=50,email"`
+	Bio        string `json:"bio" validate:"max=100"`
+	Locale     string `json:"locale" validate:"max=10"`
 }
 
 // User represents a user to be returned as a response
 type User struct {
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Handle        string `json:"handle"`
+	PhoneNo       string `json:"phone_number"`
+	ProfilePic    string `json:"profile_pic"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Bio           string `json:"bio"`
+	Locale        string `json:"locale"`
+}
+
+// EmailCodeRequest represents a request for a one-time code sent to an email
+type EmailCodeRequest struct {
+	Email string `json:"email" validate:"required,max=50,email"`
+}
+
+// EmailCodeVerify represents a one-time code sent to an email being redeemed
+type EmailCodeVerify struct {
+	Email string `json:"email" validate:"required,max=50,email"`
+	Code  string `json:"code" validate:"required"`
+}
+
+// UserDelete represents a user that has been deleted
+type UserDelete struct {
 	ID         string `json:"id"`
 	Username   string `json:"username"`
+	Handle     string `json:"handle"`
 	PhoneNo    string `json:"phone_number"`
 	ProfilePic string `json:"profile_pic"`
 	Email      string `json:"email"`
 	Bio        string `json:"bio"`
 }
 
-// UserDelete represents a user that has been deleted
-type UserDelete struct {
+// PublicUser represents a user as seen by other users, without contact details
+type PublicUser struct {
 	ID         string `json:"id"`
 	Username   string `json:"username"`
-	PhoneNo    string `json:"phone_number"`
+	Handle     string `json:"handle"`
 	ProfilePic string `json:"profile_pic"`
-	Email      string `json:"email"`
 	Bio        string `json:"bio"`
 }
+
+// UserStats represents the reading activity of a user
+type UserStats struct {
+	ClubsJoined  int `json:"clubs_joined"`
+	ClubsHosted  int `json:"clubs_hosted"`
+	ChatMessages int `json:"chat_messages"`
+	Comments     int `json:"comments"`
+}
+
+// UserProfile represents the public profile of a user
+type UserProfile struct {
+	PublicUser
+	ClubsInCommon []*Club   `json:"clubs_in_common"`
+	Stats         UserStats `json:"stats"`
+}
+
+// ClubMembership represents a club a user is a member of
+type ClubMembership struct {
+	ClubID     string    `json:"club_id"`
+	ClubName   string    `json:"clubname"`
+	Host       bool      `json:"host"`
+	TimeJoined time.Time `json:"time_joined"`
+}
+
+// UserExport represents a copy of all the data kept about a user
+type UserExport struct {
+	User           User              `json:"user"`
+	Memberships    []*ClubMembership `json:"memberships"`
+	ChatMessages   []*ChatMessage    `json:"chat_messages"`
+	Comments       []*Comment        `json:"comments"`
+	DirectMessages []*DirectMessage  `json:"direct_messages"`
+	TimeExported   time.Time         `json:"time_exported"`
+}
+
+// DeviceTokenCreate represents a device to be registered for push notifications
+type DeviceTokenCreate struct {
+	Token    string `json:"token" validate:"required,max=4096"`
+	Platform string `json:"platform" validate:"oneof=android ios web"`
+}
+
+// AdminUser represents a user as seen by platform administrators
+type AdminUser struct {
+	ID             string     `json:"id"`
+	Username       string     `json:"username"`
+	Handle         string     `json:"handle"`
+	PhoneNo        string     `json:"phone_number"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+}
@@ -0,0 +1,97 @@
+package sms
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Sender sends text messages to phone numbers
+type Sender interface {
+	Send(to string, body string) error
+}
+
+// TwilioSender sends text messages through the REST API of Twilio
+type TwilioSender struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+}
+
+// Send sends the text message through Twilio
+func (s *TwilioSender) Send(to string, body string) error {
+	form := url.Values{
+		"To":   {to},
+		"From": {s.From},
+		"Body": {body},
+	}
+	endpoint := "https://api.twilio.com/2010-04-01/Accounts/" + url.PathEscape(s.AccountSID) + "/Messages.json"
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.AccountSID, s.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("twilio returned %s", resp.Status)
+	}
+	return nil
+}
+
+// FileSender appends text messages to a file instead of sending them, for development
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Send appends the text message to the file
+func (s *FileSender) Send(to string, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), to, body)
+	return err
+}
+
+var sender Sender = nil
+
+// GetSender returns a singleton reference to the text message sender selected
+// by SMS_SENDER, nil when it names none and text messages cannot be sent
+func GetSender() Sender {
+	if sender != nil {
+		return sender
+	}
+	switch viper.GetString("SMS_SENDER") {
+	case "twilio":
+		sender = &TwilioSender{
+			AccountSID: viper.GetString("TWILIO_ACCOUNT_SID"),
+			AuthToken:  viper.GetString("TWILIO_AUTH_TOKEN"),
+			From:       viper.GetString("TWILIO_FROM"),
+		}
+	case "file":
+		path := viper.GetString("SMS_LOG_FILE")
+		if path == "" {
+			path = "sms.log"
+		}
+		sender = &FileSender{
+			Path: path,
+		}
+	}
+	return sender
+}
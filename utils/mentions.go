@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionPattern matches @handle mentions that are not part of a longer word or email
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z][A-Za-z0-9_]{2,19})\b`)
+
+// ParseMentions returns the distinct lowercase handles mentioned in a text
+func ParseMentions(text string) []string {
+	seen := make(map[string]bool)
+	handles := make([]string, 0)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		handle := strings.ToLower(match[1])
+		if seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
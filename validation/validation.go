@@ -0,0 +1,289 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// FieldError describes a field of a request that broke one of its rules
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+	// key names the message in messages
+	key string
+}
+
+// MessageKey is the key the message of the error is translated under
+func (e *FieldError) MessageKey() string {
+	return "validation." + e.key
+}
+
+// Errors are the fields of a request that failed validation
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	fields := make([]string, len(e))
+	for i, fieldErr := range e {
+		fields[i] = fieldErr.Field
+	}
+	return "invalid fields: " + strings.Join(fields, ", ")
+}
+
+// e164Pattern matches phone numbers in the E.164 format, e.g. +919876543210
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// messages are what each broken rule is reported with, {param} is replaced by
+// the parameter of the rule
+var messages = map[string]string{
+	"required":       "is required",
+	"min":            "must be at least {param}",
+	"min_characters": "must have at least {param} characters",
+	"min_items":      "must have at least {param} items",
+	"max":            "must be at most {param}",
+	"max_characters": "must have at most {param} characters",
+	"max_items":      "must have at most {param} items",
+	"url":            "must be an http or https URL",
+	"email":          "must be an email address",
+	"e164":           "must be a phone number in E.164 format",
+	"uuid":           "must be a UUID",
+	"oneof":          "must be one of {param}",
+	"clock":          "must be a time of day as HH:MM",
+	"timezone":       "must be an IANA timezone",
+}
+
+// rule checks a non-empty value against the parameter of a rule and returns
+// the key of the message to report, or an empty string if nothing is wrong
+type rule func(value reflect.Value, param string) string
+
+// newFieldError reports a field that broke a rule
+func newFieldError(name string, ruleName string, param string, key string) *FieldError {
+	return &FieldError{
+		Field:   name,
+		Rule:    ruleName,
+		Param:   param,
+		Message: strings.ReplaceAll(messages[key], "{param}", param),
+		key:     key,
+	}
+}
+
+var rules = map[string]rule{
+	"min":      checkMin,
+	"max":      checkMax,
+	"url":      checkURL,
+	"email":    checkEmail,
+	"e164":     checkE164,
+	"uuid":     checkUUID,
+	"oneof":    checkOneOf,
+	"clock":    checkClock,
+	"timezone": checkTimezone,
+}
+
+// Validate checks every field of the struct v points to against the rules in
+// its validate tag, for example `validate:"required,max=30"`.
+//
+// Lengths of strings are counted in characters rather than bytes. Fields that
+// are empty are only checked by required, all other rules skip them. The
+// error returned is of type Errors and names fields by their json, form or
+// query key.
+func Validate(v interface{}) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs Errors
+	validateStruct(value, &errs)
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(value reflect.Value, errs *Errors) {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		fieldValue := value.Field(i)
+		if field.Anonymous && fieldValue.Kind() == reflect.Struct {
+			validateStruct(fieldValue, errs)
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if fieldErr := validateField(fieldName(field), fieldValue, tag); fieldErr != nil {
+			*errs = append(*errs, fieldErr)
+		}
+	}
+}
+
+// fieldName is the key a field is sent under by clients
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "query"} {
+		name := strings.Split(field.Tag.Get(key), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// validateField applies the rules of a tag in order and stops at the first one broken
+func validateField(name string, value reflect.Value, tag string) *FieldError {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			break
+		}
+		value = value.Elem()
+	}
+	empty := isEmpty(value)
+	for _, part := range strings.Split(tag, ",") {
+		ruleName, param := part, ""
+		if i := strings.Index(part, "="); i >= 0 {
+			ruleName, param = part[:i], part[i+1:]
+		}
+		if ruleName == "required" {
+			if empty {
+				return newFieldError(name, ruleName, "", "required")
+			}
+			continue
+		}
+		if empty {
+			return nil
+		}
+		check, ok := rules[ruleName]
+		if !ok {
+			panic(fmt.Sprintf("validation: unknown rule %q on %s", ruleName, name))
+		}
+		if key := check(value, param); key != "" {
+			return newFieldError(name, ruleName, param, key)
+		}
+	}
+	return nil
+}
+
+// isEmpty reports whether a value was left out, strings of only whitespace count as empty
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// size is the number of characters of a string, the length of a slice or the
+// value of a number, along with the suffix of the message keys that apply to it
+func size(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), "_characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), "_items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), ""
+	}
+	panic("validation: min and max do not apply to " + value.Kind().String())
+}
+
+func parseLimit(param string) float64 {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic("validation: invalid limit " + param)
+	}
+	return limit
+}
+
+func checkMin(value reflect.Value, param string) string {
+	n, suffix := size(value)
+	if n < parseLimit(param) {
+		return "min" + suffix
+	}
+	return ""
+}
+
+func checkMax(value reflect.Value, param string) string {
+	n, suffix := size(value)
+	if n > parseLimit(param) {
+		return "max" + suffix
+	}
+	return ""
+}
+
+func checkURL(value reflect.Value, _ string) string {
+	u, err := url.ParseRequestURI(value.String())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "url"
+	}
+	return ""
+}
+
+func checkEmail(value reflect.Value, _ string) string {
+	email := strings.TrimSpace(value.String())
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "email"
+	}
+	return ""
+}
+
+func checkE164(value reflect.Value, _ string) string {
+	if !e164Pattern.MatchString(value.String()) {
+		return "e164"
+	}
+	return ""
+}
+
+func checkUUID(value reflect.Value, _ string) string {
+	if _, err := uuid.Parse(value.String()); err != nil {
+		return "uuid"
+	}
+	return ""
+}
+
+func checkOneOf(value reflect.Value, param string) string {
+	for _, option := range strings.Fields(param) {
+		if fmt.Sprint(value.Interface()) == option {
+			return ""
+		}
+	}
+	return "oneof"
+}
+
+func checkClock(value reflect.Value, _ string) string {
+	if _, err := time.Parse("15:04", value.String()); err != nil {
+		return "clock"
+	}
+	return ""
+}
+
+func checkTimezone(value reflect.Value, _ string) string {
+	if _, err := time.LoadLocation(value.String()); err != nil {
+		return "timezone"
+	}
+	return ""
+}